@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mm-rules/matchmaking/internal/allocation"
 	"github.com/mm-rules/matchmaking/internal/api"
+	"github.com/mm-rules/matchmaking/internal/scheduler"
 	"github.com/mm-rules/matchmaking/internal/storage"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
@@ -31,27 +33,61 @@ func main() {
 	logger := logrus.New()
 	logger.Info("Starting MM-Rules Matchmaking Server")
 
-	// Initialize Redis storage
-	redisAddr := viper.GetString("redis.addr")
-	redisPassword := viper.GetString("redis.password")
-	redisDB := viper.GetInt("redis.db")
-
-	redisStorage := storage.NewRedisStorage(redisAddr, redisPassword, redisDB)
-	defer redisStorage.Close()
-
-	// Test Redis connection
+	// Initialize storage
+	var store storage.Storage
 	ctx := context.Background()
-	if err := redisStorage.Ping(ctx); err != nil {
-		logger.Fatalf("Failed to connect to Redis: %v", err)
+	switch backend := viper.GetString("storage.backend"); backend {
+	case "memory":
+		store = storage.NewMemoryStorage()
+		logger.Info("Using in-memory storage")
+	case "redis":
+		redisAddr := viper.GetString("redis.addr")
+		redisPassword := viper.GetString("redis.password")
+		redisDB := viper.GetInt("redis.db")
+
+		redisStorage := storage.NewRedisStorage(redisAddr, redisPassword, redisDB)
+		defer redisStorage.Close()
+
+		// Test Redis connection
+		if err := redisStorage.Ping(ctx); err != nil {
+			logger.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		logger.Info("Connected to Redis")
+
+		// Index games and queues written before the index sets existed
+		if err := redisStorage.RebuildIndexes(ctx); err != nil {
+			logger.Fatalf("Failed to rebuild Redis indexes: %v", err)
+		}
+		store = redisStorage
+	default:
+		logger.Fatalf("Unknown storage backend: %s", backend)
 	}
-	logger.Info("Connected to Redis")
 
 	// Initialize allocator
 	webhookURL := viper.GetString("allocation.webhook_url")
 	var allocator allocation.Allocator = allocation.NewAllocator(webhookURL)
 
 	// Initialize API handler
-	handler := api.NewHandler(redisStorage, allocator, logger)
+	defaultTicketTTL := time.Duration(viper.GetInt("matchmaking.max_wait_time")) * time.Second
+	handler := api.NewHandler(store, allocator, logger, defaultTicketTTL)
+
+	// Start the matchmaking scheduler
+	var matchScheduler *scheduler.Scheduler
+	processInterval := time.Duration(viper.GetInt("matchmaking.process_interval")) * time.Second
+	if processInterval > 0 {
+		matchScheduler = scheduler.NewScheduler(store, func(ctx context.Context, gameID string) error {
+			_, err := handler.RunMatchmaking(ctx, gameID)
+			if errors.Is(err, api.ErrGameLocked) {
+				// Another replica is processing this game
+				return nil
+			}
+			return err
+		}, processInterval, logger)
+		matchScheduler.Start(context.Background())
+		logger.Infof("Matchmaking scheduler running every %s", processInterval)
+	} else {
+		logger.Info("Matchmaking scheduler disabled")
+	}
 
 	// Setup router
 	router := setupRouter(handler)
@@ -86,6 +122,11 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Stop scheduling new matchmaking passes before the server goes away
+	if matchScheduler != nil {
+		matchScheduler.Stop()
+	}
+
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -107,10 +148,13 @@ func loadConfig() error {
 
 	// Set defaults
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("storage.backend", "redis")
 	viper.SetDefault("redis.addr", "localhost:6379")
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
 	viper.SetDefault("allocation.webhook_url", "http://localhost:8081/allocate")
+	viper.SetDefault("matchmaking.process_interval", 5)
+	viper.SetDefault("matchmaking.max_wait_time", 300)
 	viper.SetDefault("log.level", "info")
 
 	// Read config file
@@ -163,7 +207,12 @@ func setupRouter(handler *api.Handler) *gin.Engine {
 	{
 		// Match requests
 		api.POST("/match-request", handler.CreateMatchRequest)
+		api.DELETE("/match-request/:request_id", handler.CancelMatchRequest)
+		api.POST("/match-request/:request_id/heartbeat", handler.HeartbeatMatchRequest)
 		api.GET("/match-status/:request_id", handler.GetMatchStatus)
+		api.POST("/matches/:match_id/backfill", handler.CreateBackfillRequest)
+		api.POST("/matches/:match_id/accept", handler.AcceptMatch)
+		api.POST("/matches/:match_id/decline", handler.DeclineMatch)
 
 		// Game configuration
 		api.POST("/rules/:game_id", handler.CreateGameConfig)
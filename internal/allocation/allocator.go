@@ -43,6 +43,7 @@ func (a *RealAllocator) AllocateSession(match *models.Match) (*models.GameSessio
 		GameID:   match.GameID,
 		Players:  match.Players,
 		TeamName: match.TeamName,
+		Region:   match.Region,
 	}
 
 	// Convert to JSON
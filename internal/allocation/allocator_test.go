@@ -1,11 +1,15 @@
 package allocation
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/mm-rules/matchmaking/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewAllocator(t *testing.T) {
@@ -219,4 +223,26 @@ func TestRealAllocator_ValidateAllocationRequest_MissingMatchID(t *testing.T) {
 	err := allocator.ValidateAllocationRequest(req)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "match_id is required")
-} 
\ No newline at end of file
+} 
+
+func TestRealAllocator_AllocateSession_SendsRegion(t *testing.T) {
+	var received models.AllocationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		json.NewEncoder(w).Encode(models.AllocationResponse{
+			Success: true,
+			Session: &models.GameSession{IP: "10.0.0.1", Port: 7777, ID: "session1"},
+		})
+	}))
+	defer server.Close()
+
+	allocator := NewAllocator(server.URL)
+	match := models.NewMatch("test-game", "team1", []string{"player1", "player2"})
+	match.Region = "eu-west"
+
+	session, err := allocator.AllocateSession(match)
+	require.NoError(t, err)
+	assert.Equal(t, "session1", session.ID)
+	assert.Equal(t, match.ID, received.MatchID)
+	assert.Equal(t, "eu-west", received.Region)
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/mm-rules/matchmaking/internal/allocation"
 	"github.com/mm-rules/matchmaking/internal/engine"
 	"github.com/mm-rules/matchmaking/internal/matchmaker"
@@ -23,16 +26,25 @@ type Handler struct {
 	ruleEngine *engine.RuleEngine
 	allocator  allocation.Allocator
 	logger     *logrus.Logger
+
+	defaultTicketTTL time.Duration // ticket TTL for games that don't set their own
 }
 
+// gameLockTTL is how long a replica holds the matchmaking lock for a game without renewing it
+const gameLockTTL = 15 * time.Second
+
 // NewHandler creates a new API handler
-func NewHandler(storage storage.Storage, allocator allocation.Allocator, logger *logrus.Logger) *Handler {
+func NewHandler(storage storage.Storage, allocator allocation.Allocator, logger *logrus.Logger, defaultTicketTTL time.Duration) *Handler {
+	// The matchmaker shares the rule engine that validates configs, and so the regexes it compiles
+	ruleEngine := engine.NewRuleEngine()
 	handler := &Handler{
 		storage:    storage,
-		matchmaker: matchmaker.NewMatchmaker(),
-		ruleEngine: engine.NewRuleEngine(),
+		matchmaker: matchmaker.NewMatchmakerWithRuleEngine(ruleEngine),
+		ruleEngine: ruleEngine,
 		allocator:  allocator,
 		logger:     logger,
+
+		defaultTicketTTL: defaultTicketTTL,
 	}
 
 	// Start background cleanup routine
@@ -58,9 +70,11 @@ func (h *Handler) startBackgroundCleanup() {
 
 // MatchRequestRequest represents the request body for creating a match request
 type MatchRequestRequest struct {
-	PlayerID string                 `json:"player_id" binding:"required"`
-	Metadata map[string]interface{} `json:"metadata"`
-	GameID   string                 `json:"game_id" binding:"required"`
+	PlayerID  string                 `json:"player_id" binding:"required"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	GameID    string                 `json:"game_id" binding:"required"`
+	Members   []models.PartyMember   `json:"members"`   // other players queueing together with player_id
+	Latencies map[string]int         `json:"latencies"` // region -> measured ping in ms
 }
 
 // CreateMatchRequest handles POST /match-request
@@ -74,19 +88,49 @@ func (h *Handler) CreateMatchRequest(c *gin.Context) {
 		return
 	}
 
-	// Remove any existing pending requests for this player and game
+	// Create match request
+	matchRequest := models.NewMatchRequest(req.PlayerID, req.GameID, req.Metadata)
+	matchRequest.Members = req.Members
+	matchRequest.Latencies = req.Latencies
+	if err := validateParty(matchRequest); err != nil {
+		metrics.RecordHTTPRequest("POST", "/api/v1/match-request", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A missing game config is not an error here; the ticket just waits with the default TTL.
+	// The party is checked before existing tickets are replaced, so a rejected request leaves them queued.
+	config, err := h.storage.GetGameConfig(c.Request.Context(), req.GameID)
+	if err != nil {
+		config = nil
+	}
+	if config != nil && matchRequest.Size() > 1 && !partyFits(config, matchRequest) {
+		metrics.RecordHTTPRequest("POST", "/api/v1/match-request", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("party of %d players does not fit any team", matchRequest.Size())})
+		return
+	}
+	matchRequest.TTL = h.ticketTTL(config)
+
+	// Remove any existing pending requests for this player, or any party member, and game
+	playerIDs := make(map[string]bool, matchRequest.Size())
+	for _, playerID := range matchRequest.PlayerIDs() {
+		playerIDs[playerID] = true
+	}
 	requests, err := h.storage.GetGameQueue(c.Request.Context(), req.GameID)
 	if err == nil {
 		for _, r := range requests {
-			if r.PlayerID == req.PlayerID && r.Status == models.StatusPending {
-				_ = h.storage.RemoveFromQueue(c.Request.Context(), req.GameID, r.ID)
+			if r.Status != models.StatusPending {
+				continue
+			}
+			for _, playerID := range r.PlayerIDs() {
+				if playerIDs[playerID] {
+					_ = h.storage.RemoveFromQueue(c.Request.Context(), req.GameID, r.ID)
+					break
+				}
 			}
 		}
 	}
 
-	// Create match request
-	matchRequest := models.NewMatchRequest(req.PlayerID, req.GameID, req.Metadata)
-
 	// Store in Redis
 	ctx := c.Request.Context()
 	if err := h.storage.StoreMatchRequest(ctx, matchRequest); err != nil {
@@ -103,12 +147,228 @@ func (h *Handler) CreateMatchRequest(c *gin.Context) {
 	h.logger.WithFields(logrus.Fields{
 		"request_id": matchRequest.ID,
 		"player_id":  matchRequest.PlayerID,
+		"party_size": matchRequest.Size(),
 		"game_id":    matchRequest.GameID,
 	}).Info("Created match request")
 
 	c.JSON(http.StatusCreated, gin.H{
 		"request_id": matchRequest.ID,
 		"status":     matchRequest.Status,
+		"ttl":        int(storage.RequestTTL(matchRequest).Seconds()),
+	})
+}
+
+// validateParty checks that every party member on a ticket is a distinct player and that no player
+// reports a negative ping
+func validateParty(request *models.MatchRequest) error {
+	seen := map[string]bool{request.PlayerID: true}
+	for i, member := range request.Members {
+		if member.PlayerID == "" {
+			return fmt.Errorf("members[%d]: player_id is required", i)
+		}
+		if seen[member.PlayerID] {
+			return fmt.Errorf("members[%d]: player %s is already on the ticket", i, member.PlayerID)
+		}
+		seen[member.PlayerID] = true
+	}
+	for _, player := range request.MemberRequests() {
+		for region, ping := range player.Latencies {
+			if ping < 0 {
+				return fmt.Errorf("player %s: latency to %s must not be negative", player.PlayerID, region)
+			}
+		}
+	}
+	return nil
+}
+
+// ticketTTL returns the TTL in seconds for new tickets in a game, falling back to the server default
+func (h *Handler) ticketTTL(config *models.GameConfig) int {
+	if config != nil && config.TicketTTL > 0 {
+		return config.TicketTTL
+	}
+	return int(h.defaultTicketTTL.Seconds())
+}
+
+// partyFits reports whether a ticket's party fits on at least one of the game's teams
+func partyFits(config *models.GameConfig, request *models.MatchRequest) bool {
+	for _, team := range config.Teams {
+		if request.Size() <= team.MaxPlayers() {
+			return true
+		}
+	}
+	return false
+}
+
+// HeartbeatMatchRequest handles POST /match-request/:request_id/heartbeat
+func (h *Handler) HeartbeatMatchRequest(c *gin.Context) {
+	start := time.Now()
+	requestID := c.Param("request_id")
+	if requestID == "" {
+		metrics.RecordHTTPRequest("POST", "/api/v1/match-request/heartbeat", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request_id is required"})
+		return
+	}
+
+	// Extend the ticket so it stays in the queue while the client is alive
+	request, err := h.storage.RefreshMatchRequest(c.Request.Context(), requestID)
+	if err != nil {
+		metrics.RecordHTTPRequest("POST", "/api/v1/match-request/heartbeat", "404", time.Since(start).Seconds())
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match request not found"})
+		return
+	}
+
+	metrics.RecordHTTPRequest("POST", "/api/v1/match-request/heartbeat", "200", time.Since(start).Seconds())
+	c.JSON(http.StatusOK, gin.H{
+		"request_id": request.ID,
+		"status":     request.Status,
+		"ttl":        int(storage.RequestTTL(request).Seconds()),
+	})
+}
+
+// CancelMatchRequest handles DELETE /match-request/:request_id
+func (h *Handler) CancelMatchRequest(c *gin.Context) {
+	start := time.Now()
+	requestID := c.Param("request_id")
+	if requestID == "" {
+		metrics.RecordHTTPRequest("DELETE", "/api/v1/match-request", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "request_id is required"})
+		return
+	}
+
+	request, cancelled, err := h.storage.CancelMatchRequest(c.Request.Context(), requestID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrRequestNotFound):
+			metrics.RecordHTTPRequest("DELETE", "/api/v1/match-request", "404", time.Since(start).Seconds())
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match request not found"})
+		case errors.Is(err, storage.ErrRequestClaimed):
+			metrics.RecordHTTPRequest("DELETE", "/api/v1/match-request", "409", time.Since(start).Seconds())
+			c.JSON(http.StatusConflict, gin.H{"error": "Match request has already been matched"})
+		case errors.Is(err, storage.ErrConcurrentUpdate):
+			// The request may still be pending; the client should retry
+			metrics.RecordHTTPRequest("DELETE", "/api/v1/match-request", "503", time.Since(start).Seconds())
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Match request is being updated, try again"})
+		default:
+			h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to cancel match request")
+			metrics.RecordHTTPRequest("DELETE", "/api/v1/match-request", "500", time.Since(start).Seconds())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel match request"})
+		}
+		return
+	}
+
+	metrics.RecordHTTPRequest("DELETE", "/api/v1/match-request", "200", time.Since(start).Seconds())
+
+	// Repeated cancels succeed but only the first is counted
+	if cancelled {
+		metrics.RecordMatchRequest(request.GameID, "cancelled")
+		h.logger.WithFields(logrus.Fields{
+			"request_id": request.ID,
+			"player_id":  request.PlayerID,
+			"game_id":    request.GameID,
+		}).Info("Cancelled match request")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"request_id": request.ID,
+		"status":     request.Status,
+	})
+}
+
+// BackfillRequestRequest represents the request body for requesting backfill players
+type BackfillRequestRequest struct {
+	TeamName string   `json:"team_name" binding:"required"`
+	Slots    int      `json:"slots" binding:"required,min=1"`
+	Departed []string `json:"departed"` // players leaving the team, replaced by the new players
+}
+
+// CreateBackfillRequest handles POST /matches/:match_id/backfill
+func (h *Handler) CreateBackfillRequest(c *gin.Context) {
+	start := time.Now()
+	matchID := c.Param("match_id")
+	if matchID == "" {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_id is required"})
+		return
+	}
+
+	var req BackfillRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	match, err := h.storage.GetMultiTeamMatch(ctx, matchID)
+	if err != nil {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "404", time.Since(start).Seconds())
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+
+	onTeam := make(map[string]bool, len(match.Teams[req.TeamName]))
+	for _, playerID := range match.Teams[req.TeamName] {
+		onTeam[playerID] = true
+	}
+	for _, playerID := range req.Departed {
+		if !onTeam[playerID] {
+			metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "400", time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("player '%s' is not on team '%s'", playerID, req.TeamName)})
+			return
+		}
+		delete(onTeam, playerID) // A player listed twice only leaves once
+	}
+
+	// A missing game config is not an error here; the ticket just waits with the default TTL
+	config, err := h.storage.GetGameConfig(ctx, match.GameID)
+	if err != nil {
+		config = nil
+	}
+	if config != nil {
+		var team *models.Team
+		for i := range config.Teams {
+			if config.Teams[i].Name == req.TeamName {
+				team = &config.Teams[i]
+				break
+			}
+		}
+		if team == nil {
+			metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "400", time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("team '%s' not found in game configuration", req.TeamName)})
+			return
+		}
+		// Departed players free their seats; the rest of the team keeps theirs
+		if open := max(team.MaxPlayers()-len(onTeam), 0); req.Slots > open {
+			metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "400", time.Since(start).Seconds())
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("team '%s' has %d open slots", req.TeamName, open)})
+			return
+		}
+	}
+
+	backfill := models.NewBackfillRequest(match, req.TeamName, req.Slots, req.Departed)
+	backfill.TTL = h.ticketTTL(config)
+	if err := h.storage.StoreMatchRequest(ctx, backfill); err != nil {
+		h.logger.WithError(err).Error("Failed to store backfill request")
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "500", time.Since(start).Seconds())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create backfill request"})
+		return
+	}
+
+	metrics.RecordMatchRequest(match.GameID, "backfill_created")
+	metrics.RecordHTTPRequest("POST", "/api/v1/matches/backfill", "201", time.Since(start).Seconds())
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id": backfill.ID,
+		"match_id":   match.ID,
+		"team_name":  req.TeamName,
+		"slots":      req.Slots,
+	}).Info("Created backfill request")
+
+	c.JSON(http.StatusCreated, gin.H{
+		"request_id": backfill.ID,
+		"match_id":   match.ID,
+		"status":     backfill.Status,
+		"ttl":        int(storage.RequestTTL(backfill).Seconds()),
 	})
 }
 
@@ -138,6 +398,11 @@ func (h *Handler) CreateGameConfig(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if _, ok := matchmaker.LookupStrategy(config.Strategy); !ok {
+		metrics.RecordHTTPRequest("POST", "/api/v1/rules", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown strategy '%s'", config.Strategy)})
+		return
+	}
 
 	// Store in Redis
 	ctx := c.Request.Context()
@@ -161,7 +426,7 @@ func (h *Handler) CreateGameConfig(c *gin.Context) {
 		"team_names": func() []string {
 			names := make([]string, len(config.Teams))
 			for i, team := range config.Teams {
-				names[i] = fmt.Sprintf("%s(size:%d)", team.Name, team.Size)
+				names[i] = fmt.Sprintf("%s(size:%d)", team.Name, team.MaxPlayers())
 			}
 			return names
 		}(),
@@ -175,7 +440,8 @@ func (h *Handler) CreateGameConfig(c *gin.Context) {
 	})
 }
 
-// GetMatchStatus handles GET /match-status/:request_id
+// GetMatchStatus handles GET /match-status/:request_id. Every member of a party ticket shares its
+// status; the optional player_id query parameter reports role for that member instead of the leader.
 func (h *Handler) GetMatchStatus(c *gin.Context) {
 	start := time.Now()
 	requestID := c.Param("request_id")
@@ -187,7 +453,20 @@ func (h *Handler) GetMatchStatus(c *gin.Context) {
 
 	// Try to get cached status first
 	status, err := h.storage.GetMatchStatus(c.Request.Context(), requestID)
+	if err == nil && status.Status == models.StatusAwaitingAccept && acceptWindowClosed(status) {
+		// The ready check has timed out; dissolve the match and report the request's new status
+		_ = h.expireReadyCheck(c.Request.Context(), status.MatchID)
+		status, err = h.storage.GetMatchStatus(c.Request.Context(), requestID)
+	}
 	if err == nil {
+		if playerID := c.Query("player_id"); playerID != "" && status.AllPlayers != nil {
+			if !contains(status.AllPlayers, playerID) {
+				metrics.RecordHTTPRequest("GET", "/api/v1/match-status", "400", time.Since(start).Seconds())
+				c.JSON(http.StatusBadRequest, gin.H{"error": "player is not in the match"})
+				return
+			}
+			status.Role = status.Roles[playerID]
+		}
 		metrics.RecordHTTPRequest("GET", "/api/v1/match-status", "200", time.Since(start).Seconds())
 		c.JSON(http.StatusOK, status)
 		return
@@ -229,6 +508,283 @@ func (h *Handler) GetMatchStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, statusResponse)
 }
 
+// Errors returned when accepting or declining a match
+var (
+	// ErrMatchNotAwaitingAccept is returned when a match has already been accepted or dissolved
+	ErrMatchNotAwaitingAccept = errors.New("match is not awaiting acceptance")
+	// ErrPlayerNotInMatch is returned when the player responding to a ready check is not in the match
+	ErrPlayerNotInMatch = errors.New("player is not in the match")
+	// ErrAcceptWindowClosed is returned when a match is accepted after its accept window
+	ErrAcceptWindowClosed = errors.New("accept window has closed")
+)
+
+// ReadyCheckRequest represents the request body for accepting or declining a match
+type ReadyCheckRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
+}
+
+// AcceptMatch handles POST /matches/:match_id/accept
+func (h *Handler) AcceptMatch(c *gin.Context) {
+	start := time.Now()
+	matchID := c.Param("match_id")
+	if matchID == "" {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/accept", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_id is required"})
+		return
+	}
+
+	var req ReadyCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/accept", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	match, err := h.storage.UpdateMultiTeamMatch(ctx, matchID, func(match *models.MultiTeamMatch) error {
+		if err := h.checkReadyCheck(match, req.PlayerID); err != nil {
+			return err
+		}
+		if !contains(match.Accepted, req.PlayerID) {
+			match.Accepted = append(match.Accepted, req.PlayerID)
+		}
+		if len(match.Accepted) == len(h.matchmaker.FlattenTeams(match.Teams)) {
+			match.Status = models.StatusMatched
+		}
+		return nil
+	})
+	if errors.Is(err, ErrAcceptWindowClosed) {
+		_ = h.expireReadyCheck(ctx, matchID)
+	}
+	if err != nil {
+		code := h.readyCheckErrorCode(err)
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/accept", fmt.Sprint(code), time.Since(start).Seconds())
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	if match.Status == models.StatusMatched {
+		h.finalizeReadyCheck(ctx, match)
+	}
+
+	metrics.RecordHTTPRequest("POST", "/api/v1/matches/accept", "200", time.Since(start).Seconds())
+	c.JSON(http.StatusOK, gin.H{
+		"match_id": match.ID,
+		"status":   match.Status,
+		"accepted": match.Accepted,
+	})
+}
+
+// DeclineMatch handles POST /matches/:match_id/decline
+func (h *Handler) DeclineMatch(c *gin.Context) {
+	start := time.Now()
+	matchID := c.Param("match_id")
+	if matchID == "" {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/decline", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": "match_id is required"})
+		return
+	}
+
+	var req ReadyCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/decline", "400", time.Since(start).Seconds())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	match, err := h.storage.UpdateMultiTeamMatch(ctx, matchID, func(match *models.MultiTeamMatch) error {
+		if err := h.checkReadyCheck(match, req.PlayerID); err != nil && !errors.Is(err, ErrAcceptWindowClosed) {
+			return err
+		}
+		match.Status = models.StatusFailed
+		match.Accepted = remove(match.Accepted, req.PlayerID)
+		return nil
+	})
+	if err != nil {
+		code := h.readyCheckErrorCode(err)
+		metrics.RecordHTTPRequest("POST", "/api/v1/matches/decline", fmt.Sprint(code), time.Since(start).Seconds())
+		c.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.dissolveMatch(ctx, match, "match was declined")
+
+	metrics.RecordHTTPRequest("POST", "/api/v1/matches/decline", "200", time.Since(start).Seconds())
+	c.JSON(http.StatusOK, gin.H{
+		"match_id": match.ID,
+		"status":   match.Status,
+	})
+}
+
+// checkReadyCheck checks that a match is awaiting acceptance from playerID
+func (h *Handler) checkReadyCheck(match *models.MultiTeamMatch, playerID string) error {
+	if match.Status != models.StatusAwaitingAccept {
+		return fmt.Errorf("%w: %s", ErrMatchNotAwaitingAccept, match.ID)
+	}
+	if !contains(h.matchmaker.FlattenTeams(match.Teams), playerID) {
+		return fmt.Errorf("%w: %s", ErrPlayerNotInMatch, playerID)
+	}
+	if match.AcceptBy != nil && time.Now().After(*match.AcceptBy) {
+		return fmt.Errorf("%w: %s", ErrAcceptWindowClosed, match.ID)
+	}
+	return nil
+}
+
+// readyCheckErrorCode maps an error from accepting or declining a match to an HTTP status code
+func (h *Handler) readyCheckErrorCode(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrMatchNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrPlayerNotInMatch):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrMatchNotAwaitingAccept), errors.Is(err, ErrAcceptWindowClosed):
+		return http.StatusConflict
+	default:
+		h.logger.WithError(err).Error("Failed to update ready check")
+		return http.StatusInternalServerError
+	}
+}
+
+// expireReadyCheck dissolves a match whose accept window has closed. It is a no-op if the match is gone,
+// no longer awaiting acceptance or its window is still open. It returns an error only if the match
+// could not be updated, so the ready check should be retried.
+func (h *Handler) expireReadyCheck(ctx context.Context, matchID string) error {
+	match, err := h.storage.UpdateMultiTeamMatch(ctx, matchID, func(match *models.MultiTeamMatch) error {
+		if match.Status != models.StatusAwaitingAccept || match.AcceptBy == nil || time.Now().Before(*match.AcceptBy) {
+			return ErrMatchNotAwaitingAccept
+		}
+		match.Status = models.StatusFailed
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrMatchNotAwaitingAccept) || errors.Is(err, storage.ErrMatchNotFound) {
+			return nil
+		}
+		h.logger.WithError(err).WithField("match_id", matchID).Error("Failed to expire ready check")
+		return err
+	}
+	h.dissolveMatch(ctx, match, "match was not accepted in time")
+	return nil
+}
+
+// sweepReadyChecks expires every ready check of a game whose accept window has closed. A ready check
+// leaves the index only once it has expired, so one that fails is retried on the next pass.
+func (h *Handler) sweepReadyChecks(ctx context.Context, gameID string) {
+	matchIDs, err := h.storage.ExpiredReadyChecks(ctx, gameID, time.Now())
+	if err != nil {
+		h.logger.WithError(err).WithField("game_id", gameID).Error("Failed to load expired ready checks")
+		return
+	}
+	for _, matchID := range matchIDs {
+		if err := h.expireReadyCheck(ctx, matchID); err != nil {
+			continue
+		}
+		if err := h.storage.RemoveReadyCheck(ctx, gameID, matchID); err != nil {
+			h.logger.WithError(err).WithField("match_id", matchID).Warn("Failed to remove expired ready check")
+		}
+	}
+}
+
+// finalizeReadyCheck marks every request in a fully accepted match as matched
+func (h *Handler) finalizeReadyCheck(ctx context.Context, match *models.MultiTeamMatch) {
+	for _, requestID := range match.RequestIDs {
+		status, err := h.storage.GetMatchStatus(ctx, requestID)
+		if err != nil {
+			h.logger.WithError(err).WithField("request_id", requestID).Warn("Match status not found for accepted match")
+			continue
+		}
+		status.Status = models.StatusMatched
+		status.AcceptBy = ""
+		if err := h.storage.StoreMatchStatus(ctx, requestID, status); err != nil {
+			h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to store match status")
+		}
+	}
+
+	h.logger.WithField("match_id", match.ID).Info("Match accepted by every player")
+}
+
+// dissolveMatch releases the requests of a failed ready check. Tickets whose players all accepted go
+// back in the queue with their original CreatedAt; the rest fail with reason.
+func (h *Handler) dissolveMatch(ctx context.Context, match *models.MultiTeamMatch, reason string) {
+	for _, requestID := range match.RequestIDs {
+		request, err := h.storage.GetMatchRequest(ctx, requestID)
+		if err != nil {
+			h.logger.WithError(err).WithField("request_id", requestID).Warn("Match request not found for dissolved match")
+			continue
+		}
+
+		accepted := true
+		for _, playerID := range request.PlayerIDs() {
+			if !contains(match.Accepted, playerID) {
+				accepted = false
+				break
+			}
+		}
+
+		if accepted {
+			err = h.storage.RequeueMatchRequest(ctx, request)
+			metrics.RecordMatchRequest(match.GameID, "requeued")
+		} else {
+			if err = h.storage.UpdateMatchRequestStatus(ctx, requestID, models.StatusFailed); err == nil {
+				err = h.storage.StoreMatchStatus(ctx, requestID, &models.MatchStatusResponse{
+					Status:  models.StatusFailed,
+					MatchID: match.ID,
+					Error:   &reason,
+				})
+			}
+		}
+		if err != nil {
+			h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to release request from dissolved match")
+		}
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"match_id": match.ID,
+		"reason":   reason,
+	}).Info("Dissolved match")
+}
+
+// acceptWindowClosed reports whether the accept deadline on a status response has passed
+func acceptWindowClosed(status *models.MatchStatusResponse) bool {
+	acceptBy, err := time.Parse(time.RFC3339, status.AcceptBy)
+	return err == nil && time.Now().After(acceptBy)
+}
+
+// contains reports whether values includes value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// remove returns values without value
+func remove(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// ErrGameConfigNotFound is returned by RunMatchmaking when the game has no stored configuration
+var ErrGameConfigNotFound = errors.New("game configuration not found")
+
+// ErrGameLocked is returned by RunMatchmaking when another replica is already processing the game
+var ErrGameLocked = errors.New("matchmaking already in progress for game")
+
+// MatchmakingResult holds the outcome of a single matchmaking pass
+type MatchmakingResult struct {
+	QueueSize int
+	Matches   []*models.MultiTeamMatch
+	Backfills []*models.MultiTeamMatch // running matches that received backfill players
+}
+
 // ProcessMatchmaking handles POST /process-matchmaking/:game_id
 func (h *Handler) ProcessMatchmaking(c *gin.Context) {
 	start := time.Now()
@@ -239,15 +795,144 @@ func (h *Handler) ProcessMatchmaking(c *gin.Context) {
 		return
 	}
 
-	_ = h.storage.CleanupExpiredRequests(c.Request.Context())
-
-	config, err := h.storage.GetGameConfig(c.Request.Context(), gameID)
+	result, err := h.RunMatchmaking(c.Request.Context(), gameID)
 	if err != nil {
-		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "404", time.Since(start).Seconds())
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game configuration not found"})
+		if errors.Is(err, ErrGameConfigNotFound) {
+			metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "404", time.Since(start).Seconds())
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game configuration not found"})
+			return
+		}
+		if errors.Is(err, ErrGameLocked) {
+			metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "409", time.Since(start).Seconds())
+			c.JSON(http.StatusConflict, gin.H{"error": "Matchmaking already in progress for this game"})
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get game queue")
+		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "500", time.Since(start).Seconds())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get match requests"})
 		return
 	}
 
+	if result.QueueSize == 0 {
+		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "200", time.Since(start).Seconds())
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No pending match requests",
+			"matches": []interface{}{},
+		})
+		return
+	}
+
+	if len(result.Matches) == 0 && len(result.Backfills) == 0 {
+		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "200", time.Since(start).Seconds())
+		c.JSON(http.StatusOK, gin.H{
+			"message": "No matches could be formed",
+			"matches": []interface{}{},
+		})
+		return
+	}
+
+	matchResults := make([]gin.H, 0, len(result.Matches))
+	for _, match := range result.Matches {
+		matchResults = append(matchResults, gin.H{
+			"match_id":   match.ID,
+			"teams":      match.Teams,
+			"created_at": match.CreatedAt.Format(time.RFC3339),
+			"region":     match.Region,
+		})
+	}
+
+	backfillResults := make([]gin.H, 0, len(result.Backfills))
+	for _, match := range result.Backfills {
+		backfillResults = append(backfillResults, gin.H{
+			"match_id": match.ID,
+			"teams":    match.Teams,
+		})
+	}
+
+	metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "200", time.Since(start).Seconds())
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Matchmaking processed successfully",
+		"matches":   matchResults,
+		"backfills": backfillResults,
+	})
+}
+
+// RunMatchmaking runs a single matchmaking pass for a game and stores the resulting matches.
+// It is shared by the manual HTTP trigger and the background scheduler, and holds the game's
+// matchmaking lock for the whole pass so replicas never process the same game concurrently.
+func (h *Handler) RunMatchmaking(ctx context.Context, gameID string) (*MatchmakingResult, error) {
+	var result *MatchmakingResult
+	err := h.withGameLock(ctx, gameID, func(ctx context.Context) error {
+		var err error
+		result, err = h.runMatchmaking(ctx, gameID)
+		return err
+	})
+	return result, err
+}
+
+// withGameLock runs fn while holding the matchmaking lock for a game, renewing it in the background.
+// Each call owns the lock under its own token, so two passes in the same replica exclude each other too.
+// The context passed to fn is cancelled if the lock is lost.
+func (h *Handler) withGameLock(ctx context.Context, gameID string, fn func(ctx context.Context) error) error {
+	token := uuid.New().String()
+	acquired, err := h.storage.AcquireGameLock(ctx, gameID, token, gameLockTTL)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrGameLocked
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	renewDone := make(chan struct{})
+	go func() {
+		defer close(renewDone)
+		ticker := time.NewTicker(gameLockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-lockCtx.Done():
+				return
+			case <-ticker.C:
+				renewed, err := h.storage.RenewGameLock(lockCtx, gameID, token, gameLockTTL)
+				if err != nil || !renewed {
+					if lockCtx.Err() == nil {
+						h.logger.WithError(err).WithField("game_id", gameID).Warn("Lost matchmaking lock, aborting pass")
+						cancel()
+					}
+					return
+				}
+			}
+		}
+	}()
+
+	err = fn(lockCtx)
+	cancel()
+	<-renewDone
+
+	// Release with a fresh context so a cancelled request still frees the lock
+	if releaseErr := h.storage.ReleaseGameLock(context.Background(), gameID, token); releaseErr != nil {
+		h.logger.WithError(releaseErr).WithField("game_id", gameID).Error("Failed to release matchmaking lock")
+	}
+
+	return err
+}
+
+// runMatchmaking forms and commits matches for a game; the caller must hold the game's lock
+func (h *Handler) runMatchmaking(ctx context.Context, gameID string) (*MatchmakingResult, error) {
+	start := time.Now()
+
+	// Drop expired requests first so neither the HTTP trigger nor the scheduler matches them
+	if err := h.storage.CleanupExpiredRequests(ctx); err != nil {
+		h.logger.WithError(err).WithField("game_id", gameID).Warn("Failed to clean up expired requests")
+	}
+
+	config, err := h.storage.GetGameConfig(ctx, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGameConfigNotFound, err)
+	}
+
 	h.logger.WithFields(logrus.Fields{
 		"game_id": config.GameID,
 		"teams":   config.Teams,
@@ -261,124 +946,292 @@ func (h *Handler) ProcessMatchmaking(c *gin.Context) {
 		"team_names": func() []string {
 			names := make([]string, len(config.Teams))
 			for i, team := range config.Teams {
-				names[i] = fmt.Sprintf("%s(size:%d)", team.Name, team.Size)
+				names[i] = fmt.Sprintf("%s(size:%d)", team.Name, team.MaxPlayers())
 			}
 			return names
 		}(),
 		"rule_count": len(config.Rules),
 	}).Info("Game config details for matchmaking")
 
-	requests, err := h.storage.GetGameQueue(c.Request.Context(), gameID)
+	// Dissolve timed-out ready checks first so their requeued tickets join this pass
+	h.sweepReadyChecks(ctx, gameID)
+
+	requests, err := h.storage.GetGameQueue(ctx, gameID)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to get game queue")
-		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "500", time.Since(start).Seconds())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get match requests"})
-		return
+		return nil, fmt.Errorf("failed to get game queue: %w", err)
 	}
 
 	metrics.SetQueueSize(gameID, len(requests))
 
+	result := &MatchmakingResult{QueueSize: len(requests)}
 	if len(requests) == 0 {
-		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "200", time.Since(start).Seconds())
-		c.JSON(http.StatusOK, gin.H{
-			"message": "No pending match requests",
-			"matches": []interface{}{},
-		})
-		return
+		return result, nil
 	}
 
-	// Use ProcessFullTeamMatchPool for multi-team support
+	// Backfill tickets take priority over forming new matches
+	requests, result.Backfills = h.runBackfills(ctx, requests, config)
+
+	// The game config's strategy decides which tickets are matched together
 	multiTeamMatches := h.matchmaker.ProcessFullTeamMatchPool(requests, config)
 	metrics.RecordMatchmakingDuration(gameID, time.Since(start).Seconds())
 
-	if len(multiTeamMatches) == 0 {
-		metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "200", time.Since(start).Seconds())
-		c.JSON(http.StatusOK, gin.H{
-			"message": "No matches could be formed",
-			"matches": []interface{}{},
-		})
-		return
+	// Map every player, including party members, to their ticket, and every ticket to its leader
+	requestIDs := make(map[string]string)
+	leaders := make(map[string]string)
+	for _, req := range requests {
+		if req.Backfill != nil {
+			continue
+		}
+		leaders[req.ID] = req.PlayerID
+		for _, playerID := range req.PlayerIDs() {
+			requestIDs[playerID] = req.ID
+		}
 	}
 
-	var matchResults []gin.H
 	for _, match := range multiTeamMatches {
 		h.logger.WithFields(logrus.Fields{
 			"match_id": match.ID,
 			"teams":    match.Teams,
-		}).Info("Storing multi-team match and updating all request statuses")
-
-		if err := h.storage.StoreMultiTeamMatch(c.Request.Context(), match); err != nil {
-			h.logger.WithError(err).Error("Failed to store multi-team match")
-			continue
-		}
-
-		metrics.RecordMatchCreated(gameID, len(h.matchmaker.FlattenTeams(match.Teams)))
+		}).Info("Committing multi-team match and updating all request statuses")
 
-		// For each team, for each player, update status and mapping
+		// Build the status response for every request in the match. Every member of a party
+		// ticket shares the ticket's status, whose role is the leader's; GetMatchStatus resolves
+		// each member's own role from roles.
+		allPlayers := h.matchmaker.FlattenTeams(match.Teams)
+		statuses := make(map[string]*models.MatchStatusResponse)
 		for teamName, playerIDs := range match.Teams {
 			for _, playerID := range playerIDs {
-				// Find the request ID for this player
-				var requestID string
-				for _, req := range requests {
-					if req.PlayerID == playerID {
-						requestID = req.ID
-						break
-					}
-				}
-				if requestID == "" {
+				requestID, ok := requestIDs[playerID]
+				if !ok {
 					h.logger.WithField("player_id", playerID).Warn("No request ID found for player")
 					continue
 				}
-
-				if err := h.storage.UpdateMatchRequestStatus(c.Request.Context(), requestID, models.StatusMatched); err != nil {
-					h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to update request status")
-				}
-				if err := h.storage.RemoveFromQueue(c.Request.Context(), gameID, requestID); err != nil {
-					h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to remove request from queue")
-				}
-				if err := h.storage.StoreRequestMatchMapping(c.Request.Context(), requestID, match.ID); err != nil {
-					h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to store request-match mapping")
+				if _, ok := statuses[requestID]; ok {
+					continue // Another member of the same party
 				}
 
 				// Build teammates (all players on the same team)
 				teammates := make([]string, 0, len(playerIDs))
-				for _, pid := range playerIDs {
-					teammates = append(teammates, pid)
-				}
-				// Build all players in match
-				allPlayers := h.matchmaker.FlattenTeams(match.Teams)
+				teammates = append(teammates, playerIDs...)
 
-				statusResp := &models.MatchStatusResponse{
+				statuses[requestID] = &models.MatchStatusResponse{
 					Status:     models.StatusMatched,
 					MatchID:    match.ID,
 					Players:    teammates,
 					TeamName:   teamName,
 					CreatedAt:  match.CreatedAt.Format(time.RFC3339),
 					AllPlayers: allPlayers,
-				}
-				if err := h.storage.StoreMatchStatus(c.Request.Context(), requestID, statusResp); err != nil {
-					h.logger.WithError(err).WithField("request_id", requestID).Error("Failed to store match status response")
+					Role:       match.Roles[leaders[requestID]],
+					Roles:      match.Roles,
+					Region:     match.Region,
 				}
 			}
 		}
 
-		matchResults = append(matchResults, gin.H{
-			"match_id":   match.ID,
-			"teams":      match.Teams,
-			"created_at": match.CreatedAt.Format(time.RFC3339),
-		})
+		match.RequestIDs = make([]string, 0, len(statuses))
+		for requestID := range statuses {
+			match.RequestIDs = append(match.RequestIDs, requestID)
+		}
+		sort.Strings(match.RequestIDs)
+
+		// With a ready check, the match waits for every player to accept before it is final
+		match.Status = models.StatusMatched
+		if config.AcceptWindow > 0 {
+			acceptBy := match.CreatedAt.Add(time.Duration(config.AcceptWindow) * time.Second)
+			match.Status = models.StatusAwaitingAccept
+			match.AcceptBy = &acceptBy
+			for _, status := range statuses {
+				status.Status = models.StatusAwaitingAccept
+				status.AcceptBy = acceptBy.Format(time.RFC3339)
+			}
+		}
+
+		// Store the match and claim every request in a single transaction
+		if err := h.storage.CommitMatch(ctx, match, statuses); err != nil {
+			if errors.Is(err, storage.ErrRequestClaimed) {
+				h.logger.WithError(err).WithField("match_id", match.ID).Warn("Discarding match with an already claimed request")
+			} else {
+				h.logger.WithError(err).WithField("match_id", match.ID).Error("Failed to commit multi-team match")
+			}
+			continue
+		}
+
+		metrics.RecordMatchCreated(gameID, len(allPlayers))
+
+		result.Matches = append(result.Matches, match)
 	}
 
 	h.logger.WithFields(logrus.Fields{
 		"game_id": gameID,
-		"matches": len(matchResults),
+		"matches": len(result.Matches),
 	}).Info("Processed matchmaking")
 
-	metrics.RecordHTTPRequest("POST", "/api/v1/process-matchmaking", "200", time.Since(start).Seconds())
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Matchmaking processed successfully",
-		"matches": matchResults,
+	return result, nil
+}
+
+// runBackfills fills pending backfill tickets in queue order from the regular tickets in requests. Each
+// filled ticket adds its players to the backfilled team of the stored match, in the role slots left open,
+// and they share the match's session. The statuses of the players already in the match are refreshed with
+// the new roster. It returns the requests left unclaimed and the matches that were backfilled.
+func (h *Handler) runBackfills(ctx context.Context, requests []*models.MatchRequest, config *models.GameConfig) ([]*models.MatchRequest, []*models.MultiTeamMatch) {
+	var backfilled []*models.MultiTeamMatch
+	claimed := make(map[string]bool)
+
+	for _, backfill := range requests {
+		if backfill.Backfill == nil || backfill.Status != models.StatusPending {
+			continue
+		}
+		match, err := h.storage.GetMultiTeamMatch(ctx, backfill.Backfill.MatchID)
+		if err != nil {
+			h.logger.WithError(err).WithField("request_id", backfill.ID).Warn("Backfill match not found")
+			continue
+		}
+		if match.Status == models.StatusAwaitingAccept || match.Status == models.StatusFailed {
+			continue // Only running matches are backfilled
+		}
+		// The departed players' seats and roles are open, and they no longer anchor the candidates
+		match.RemovePlayers(backfill.Backfill.Departed)
+
+		var available []*models.MatchRequest
+		for _, req := range requests {
+			if req.Backfill == nil && !claimed[req.ID] {
+				available = append(available, req)
+			}
+		}
+		selected := h.matchmaker.ProcessBackfill(backfill, match, available, config)
+		if selected == nil {
+			continue
+		}
+
+		// The match is re-read when committed, so players, roles and the session recorded since are kept
+		teamName := backfill.Backfill.TeamName
+		var maxPlayers int
+		for _, team := range config.Teams {
+			if team.Name == teamName {
+				maxPlayers = team.MaxPlayers()
+			}
+		}
+		var existingRequestIDs, newPlayers []string
+		var statuses map[string]*models.MatchStatusResponse
+		match, err = h.storage.CommitBackfill(ctx, match.ID, func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error) {
+			match.RemovePlayers(backfill.Backfill.Departed)
+			existingRequestIDs, newPlayers = match.RequestIDs, nil
+			for _, req := range selected {
+				newPlayers = append(newPlayers, req.PlayerIDs()...)
+			}
+			if open := maxPlayers - len(match.Teams[teamName]); len(newPlayers) > open {
+				return nil, fmt.Errorf("team '%s' has %d open slots", teamName, open)
+			}
+
+			if match.PlayerMetadata == nil {
+				match.PlayerMetadata = make(map[string]map[string]interface{})
+			}
+			if roles := h.matchmaker.BackfillRoles(match, config, teamName, selected); len(roles) > 0 {
+				if match.Roles == nil {
+					match.Roles = make(map[string]string)
+				}
+				for playerID, role := range roles {
+					match.Roles[playerID] = role
+				}
+			}
+			for _, req := range selected {
+				match.RequestIDs = append(match.RequestIDs, req.ID)
+				for _, member := range req.MemberRequests() {
+					match.PlayerMetadata[member.PlayerID] = member.Metadata
+				}
+			}
+			match.Teams[teamName] = append(match.Teams[teamName], newPlayers...)
+
+			// The new players get the match's status; the backfill ticket reports the players it received
+			allPlayers := h.matchmaker.FlattenTeams(match.Teams)
+			statuses = make(map[string]*models.MatchStatusResponse, len(selected)+1)
+			for _, req := range append(selected, backfill) {
+				players := match.Teams[teamName]
+				if req == backfill {
+					players = newPlayers
+				}
+				statuses[req.ID] = &models.MatchStatusResponse{
+					Status:     models.StatusMatched,
+					MatchID:    match.ID,
+					Players:    players,
+					TeamName:   teamName,
+					Session:    match.Session,
+					CreatedAt:  match.CreatedAt.Format(time.RFC3339),
+					AllPlayers: allPlayers,
+					Role:       match.Roles[req.PlayerID],
+					Roles:      match.Roles,
+					Region:     match.Region,
+				}
+			}
+			return statuses, nil
+		})
+		if err != nil {
+			h.logger.WithError(err).WithField("match_id", backfill.Backfill.MatchID).Warn("Failed to commit backfill")
+			continue
+		}
+		for requestID := range statuses {
+			claimed[requestID] = true
+		}
+		h.refreshRoster(ctx, match, existingRequestIDs)
+		metrics.RecordMatchRequest(match.GameID, "backfilled")
+
+		h.logger.WithFields(logrus.Fields{
+			"request_id": backfill.ID,
+			"match_id":   match.ID,
+			"team_name":  teamName,
+			"players":    newPlayers,
+		}).Info("Backfilled match")
+		backfilled = append(backfilled, match)
+	}
+
+	var remaining []*models.MatchRequest
+	for _, req := range requests {
+		if !claimed[req.ID] {
+			remaining = append(remaining, req)
+		}
+	}
+	return remaining, backfilled
+}
+
+// refreshRoster updates the stored statuses of a match's requests with its current teams and roles
+func (h *Handler) refreshRoster(ctx context.Context, match *models.MultiTeamMatch, requestIDs []string) {
+	allPlayers := h.matchmaker.FlattenTeams(match.Teams)
+	for _, requestID := range requestIDs {
+		status, err := h.storage.GetMatchStatus(ctx, requestID)
+		if err != nil || status.MatchID != match.ID {
+			continue // The status has expired or the request was requeued
+		}
+		status.Players = match.Teams[status.TeamName]
+		status.AllPlayers = allPlayers
+		status.Roles = match.Roles
+		if err := h.storage.StoreMatchStatus(ctx, requestID, status); err != nil {
+			h.logger.WithError(err).WithField("request_id", requestID).Warn("Failed to refresh match status roster")
+		}
+	}
+}
+
+// recordSession stores an allocated session on its multi-team match and on the statuses of the match's
+// requests, so players who check their status, and players backfilled later, get the session
+func (h *Handler) recordSession(ctx context.Context, matchID string, session *models.GameSession) error {
+	match, err := h.storage.UpdateMultiTeamMatch(ctx, matchID, func(match *models.MultiTeamMatch) error {
+		match.Session = session
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	for _, requestID := range match.RequestIDs {
+		status, err := h.storage.GetMatchStatus(ctx, requestID)
+		if err != nil || status.MatchID != match.ID {
+			continue
+		}
+		status.Session = session
+		if err := h.storage.StoreMatchStatus(ctx, requestID, status); err != nil {
+			h.logger.WithError(err).WithField("request_id", requestID).Warn("Failed to store session on match status")
+		}
+	}
+	return nil
 }
 
 // AllocateSessions handles POST /allocate-sessions/:game_id
@@ -400,6 +1253,12 @@ func (h *Handler) AllocateSessions(c *gin.Context) {
 
 	results := make([]gin.H, 0, len(matches))
 	for _, match := range matches {
+		// Clients that don't echo the region get the one chosen when the match was formed
+		if match.Region == "" {
+			if stored, err := h.storage.GetMultiTeamMatch(c.Request.Context(), match.ID); err == nil {
+				match.Region = stored.Region
+			}
+		}
 		metrics.RecordAllocationRequest(gameID, "requested")
 		session, err := h.allocator.AllocateSession(match)
 		if err != nil {
@@ -410,6 +1269,9 @@ func (h *Handler) AllocateSessions(c *gin.Context) {
 			})
 			continue
 		}
+		if err := h.recordSession(c.Request.Context(), match.ID, session); err != nil && !errors.Is(err, storage.ErrMatchNotFound) {
+			h.logger.WithError(err).WithField("match_id", match.ID).Error("Failed to store allocated session")
+		}
 		results = append(results, gin.H{
 			"match_id": match.ID,
 			"session":  session,
@@ -4,18 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/mm-rules/matchmaking/internal/models"
 	"github.com/mm-rules/matchmaking/internal/matchmaker"
 	"github.com/mm-rules/matchmaking/internal/engine"
+	"github.com/mm-rules/matchmaking/internal/metrics"
+	"github.com/mm-rules/matchmaking/internal/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockStorage implements the storage interface for testing
@@ -33,6 +41,11 @@ func (m *MockStorage) GetGameConfig(ctx context.Context, gameID string) (*models
 	return args.Get(0).(*models.GameConfig), args.Error(1)
 }
 
+func (m *MockStorage) GetGameIDs(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (m *MockStorage) StoreMatchRequest(ctx context.Context, request *models.MatchRequest) error {
 	args := m.Called(ctx, request)
 	return args.Error(0)
@@ -73,6 +86,109 @@ func (m *MockStorage) Ping(ctx context.Context) error {
 	return args.Error(0)
 }
 
+func (m *MockStorage) StoreRequestMatchMapping(ctx context.Context, requestID, matchID string) error {
+	args := m.Called(ctx, requestID, matchID)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetMatchIDForRequest(ctx context.Context, requestID string) (string, error) {
+	args := m.Called(ctx, requestID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) RemoveFromQueue(ctx context.Context, gameID, requestID string) error {
+	args := m.Called(ctx, gameID, requestID)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetMatch(ctx context.Context, matchID string) (*models.Match, error) {
+	args := m.Called(ctx, matchID)
+	return args.Get(0).(*models.Match), args.Error(1)
+}
+
+func (m *MockStorage) StoreMatchStatus(ctx context.Context, requestID string, status *models.MatchStatusResponse) error {
+	args := m.Called(ctx, requestID, status)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CleanupExpiredRequests(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockStorage) StoreMultiTeamMatch(ctx context.Context, match *models.MultiTeamMatch) error {
+	args := m.Called(ctx, match)
+	return args.Error(0)
+}
+
+func (m *MockStorage) GetMultiTeamMatch(ctx context.Context, matchID string) (*models.MultiTeamMatch, error) {
+	args := m.Called(ctx, matchID)
+	return args.Get(0).(*models.MultiTeamMatch), args.Error(1)
+}
+
+func (m *MockStorage) UpdateMultiTeamMatch(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) error) (*models.MultiTeamMatch, error) {
+	args := m.Called(ctx, matchID, update)
+	return args.Get(0).(*models.MultiTeamMatch), args.Error(1)
+}
+
+func (m *MockStorage) RequeueMatchRequest(ctx context.Context, request *models.MatchRequest) error {
+	args := m.Called(ctx, request)
+	return args.Error(0)
+}
+
+func (m *MockStorage) RefreshMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, error) {
+	args := m.Called(ctx, requestID)
+	return args.Get(0).(*models.MatchRequest), args.Error(1)
+}
+
+func (m *MockStorage) CancelMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, bool, error) {
+	args := m.Called(ctx, requestID)
+	return args.Get(0).(*models.MatchRequest), args.Bool(1), args.Error(2)
+}
+
+func (m *MockStorage) CommitMatch(ctx context.Context, match *models.MultiTeamMatch, statuses map[string]*models.MatchStatusResponse) error {
+	args := m.Called(ctx, match, statuses)
+	return args.Error(0)
+}
+
+func (m *MockStorage) CommitBackfill(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error)) (*models.MultiTeamMatch, error) {
+	args := m.Called(ctx, matchID, update)
+	return args.Get(0).(*models.MultiTeamMatch), args.Error(1)
+}
+
+func (m *MockStorage) AcquireGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, gameID, owner, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStorage) RenewGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, gameID, owner, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStorage) ReleaseGameLock(ctx context.Context, gameID, owner string) error {
+	args := m.Called(ctx, gameID, owner)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ExpiredReadyChecks(ctx context.Context, gameID string, now time.Time) ([]string, error) {
+	args := m.Called(ctx, gameID, now)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockStorage) RemoveReadyCheck(ctx context.Context, gameID, matchID string) error {
+	args := m.Called(ctx, gameID, matchID)
+	return args.Error(0)
+}
+
+// expectGameLock sets up a successful acquire and release of the matchmaking lock for gameID
+func (m *MockStorage) expectGameLock(gameID string) {
+	m.On("AcquireGameLock", mock.Anything, gameID, mock.AnythingOfType("string"), gameLockTTL).Return(true, nil)
+	m.On("ReleaseGameLock", mock.Anything, gameID, mock.AnythingOfType("string")).Return(nil)
+	m.On("CleanupExpiredRequests", mock.Anything).Return(nil).Maybe()
+	m.On("ExpiredReadyChecks", mock.Anything, gameID, mock.Anything).Return([]string(nil), nil).Maybe()
+}
+
 type MockAllocator struct {
 	mock.Mock
 }
@@ -106,6 +222,8 @@ func setupTestHandler() (*Handler, *MockStorage, *MockAllocator) {
 		ruleEngine: engine.NewRuleEngine(),
 		allocator:  mockAllocator,
 		logger:     logger,
+
+		defaultTicketTTL: 300 * time.Second,
 	}
 	
 	return handler, mockStorage, mockAllocator
@@ -136,7 +254,7 @@ func TestHandler_CreateGameConfig_Success(t *testing.T) {
 		Teams: []models.Team{
 			{Name: "team1", Size: 2},
 		},
-		Rules: []models.Rule{{Field: "level", Min: &[]int{10}[0]}},
+		Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
 	}
 	
 	mockStorage.On("StoreGameConfig", mock.Anything, mock.AnythingOfType("*models.GameConfig")).Return(nil)
@@ -172,6 +290,32 @@ func TestHandler_CreateGameConfig_InvalidJSON(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHandler_CreateGameConfig_UnknownStrategy(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	config := &models.GameConfig{
+		GameID:   "test-game",
+		Teams:    []models.Team{{Name: "team1", Size: 2}},
+		Rules:    []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
+		Strategy: "random",
+	}
+
+	body, _ := json.Marshal(config)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/rules/test-game", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Params = gin.Params{{Key: "game_id", Value: "test-game"}}
+
+	handler.CreateGameConfig(ctx)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown strategy 'random'")
+	mockStorage.AssertNotCalled(t, "StoreGameConfig", mock.Anything, mock.Anything)
+}
+
 func TestHandler_CreateGameConfig_StorageError(t *testing.T) {
 	handler, mockStorage, _ := setupTestHandler()
 	
@@ -180,7 +324,7 @@ func TestHandler_CreateGameConfig_StorageError(t *testing.T) {
 		Teams: []models.Team{
 			{Name: "team1", Size: 2},
 		},
-		Rules: []models.Rule{{Field: "level", Min: &[]int{10}[0]}},
+		Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
 	}
 	
 	mockStorage.On("StoreGameConfig", mock.Anything, mock.AnythingOfType("*models.GameConfig")).Return(assert.AnError)
@@ -209,7 +353,11 @@ func TestHandler_CreateMatchRequest_Success(t *testing.T) {
 		Metadata: map[string]interface{}{"level": 10},
 	}
 	
-	mockStorage.On("StoreMatchRequest", mock.Anything, mock.AnythingOfType("*models.MatchRequest")).Return(nil)
+	mockStorage.On("GetGameQueue", mock.Anything, "test-game").Return([]*models.MatchRequest{}, nil)
+	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return(&models.GameConfig{GameID: "test-game", TicketTTL: 120}, nil)
+	mockStorage.On("StoreMatchRequest", mock.Anything, mock.MatchedBy(func(r *models.MatchRequest) bool {
+		return r.TTL == 120
+	})).Return(nil)
 	
 	body, _ := json.Marshal(request)
 	w := httptest.NewRecorder()
@@ -228,10 +376,115 @@ func TestHandler_CreateMatchRequest_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, response["request_id"])
 	assert.Equal(t, "pending", response["status"])
+	assert.Equal(t, float64(120), response["ttl"])
 	
 	mockStorage.AssertExpectations(t)
 }
 
+func TestHandler_CreateMatchRequest_DefaultTicketTTL(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	request := &MatchRequestRequest{PlayerID: "player1", GameID: "test-game"}
+
+	mockStorage.On("GetGameQueue", mock.Anything, "test-game").Return([]*models.MatchRequest{}, nil)
+	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return(&models.GameConfig{GameID: "test-game"}, nil)
+	mockStorage.On("StoreMatchRequest", mock.Anything, mock.MatchedBy(func(r *models.MatchRequest) bool {
+		return r.TTL == 300
+	})).Return(nil)
+
+	body, _ := json.Marshal(request)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/match-request", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+
+	handler.CreateMatchRequest(ctx)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestHandler_CreateMatchRequest_InvalidParty(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []models.PartyMember
+		config  *models.GameConfig
+	}{
+		{
+			name:    "Duplicate member",
+			members: []models.PartyMember{{PlayerID: "player2"}, {PlayerID: "player2"}},
+		},
+		{
+			name:    "Leader listed as member",
+			members: []models.PartyMember{{PlayerID: "player1"}},
+		},
+		{
+			name:    "Negative member latency",
+			members: []models.PartyMember{{PlayerID: "player2", Latencies: map[string]int{"us-west": -5}}},
+		},
+		{
+			name:    "Party larger than every team",
+			members: []models.PartyMember{{PlayerID: "player2"}, {PlayerID: "player3"}},
+			config:  &models.GameConfig{GameID: "test-game", Teams: []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockStorage, _ := setupTestHandler()
+			if tt.config != nil {
+				mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return(tt.config, nil)
+			}
+
+			body, _ := json.Marshal(&MatchRequestRequest{PlayerID: "player1", GameID: "test-game", Members: tt.members})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/match-request", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = req
+
+			handler.CreateMatchRequest(ctx)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockStorage.AssertExpectations(t)
+			mockStorage.AssertNotCalled(t, "StoreMatchRequest", mock.Anything, mock.Anything)
+			// A rejected ticket doesn't replace the players' queued tickets
+			mockStorage.AssertNotCalled(t, "RemoveFromQueue", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestHandler_CreateMatchRequest_RejectedPartyKeepsQueuedTicket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+	}))
+	queued := models.NewMatchRequest("player2", "test-game", nil)
+	require.NoError(t, store.StoreMatchRequest(ctx, queued))
+
+	members := []models.PartyMember{{PlayerID: "player2"}, {PlayerID: "player3"}}
+	body, _ := json.Marshal(&MatchRequestRequest{PlayerID: "player1", GameID: "test-game", Members: members})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/v1/match-request", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler.CreateMatchRequest(c)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	queue, err := store.GetGameQueue(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, queued.ID, queue[0].ID)
+}
+
 func TestHandler_CreateMatchRequest_InvalidJSON(t *testing.T) {
 	handler, _, _ := setupTestHandler()
 	
@@ -256,6 +509,8 @@ func TestHandler_CreateMatchRequest_StorageError(t *testing.T) {
 		Metadata: map[string]interface{}{"level": 10},
 	}
 	
+	mockStorage.On("GetGameQueue", mock.Anything, "test-game").Return([]*models.MatchRequest{}, nil)
+	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return((*models.GameConfig)(nil), assert.AnError)
 	mockStorage.On("StoreMatchRequest", mock.Anything, mock.AnythingOfType("*models.MatchRequest")).Return(assert.AnError)
 	
 	body, _ := json.Marshal(request)
@@ -319,6 +574,157 @@ func TestHandler_GetMatchStatus_NotFound(t *testing.T) {
 	mockStorage.AssertExpectations(t)
 }
 
+func TestHandler_HeartbeatMatchRequest_Success(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	request := models.NewMatchRequest("player1", "test-game", nil)
+	request.ID = "req1"
+	request.TTL = 120
+	mockStorage.On("RefreshMatchRequest", mock.Anything, "req1").Return(request, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/match-request/req1/heartbeat", nil)
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Params = gin.Params{{Key: "request_id", Value: "req1"}}
+
+	handler.HeartbeatMatchRequest(ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "req1", response["request_id"])
+	assert.Equal(t, "pending", response["status"])
+	assert.Equal(t, float64(120), response["ttl"])
+
+	mockStorage.AssertExpectations(t)
+}
+
+func TestHandler_HeartbeatMatchRequest_NotFound(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	mockStorage.On("RefreshMatchRequest", mock.Anything, "req1").Return((*models.MatchRequest)(nil), assert.AnError)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/match-request/req1/heartbeat", nil)
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Params = gin.Params{{Key: "request_id", Value: "req1"}}
+
+	handler.HeartbeatMatchRequest(ctx)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestHandler_CancelMatchRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    *models.MatchRequest
+		err        error
+		wantStatus int
+	}{
+		{
+			name:       "Cancelled",
+			request:    &models.MatchRequest{ID: "req1", GameID: "test-game", Status: models.StatusCancelled},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Already matched",
+			err:        fmt.Errorf("%w: req1 is matched", storage.ErrRequestClaimed),
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "Not found",
+			err:        fmt.Errorf("%w: req1", storage.ErrRequestNotFound),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Contended",
+			err:        fmt.Errorf("%w while cancelling req1", storage.ErrConcurrentUpdate),
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Storage error",
+			err:        assert.AnError,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockStorage, _ := setupTestHandler()
+			mockStorage.On("CancelMatchRequest", mock.Anything, "req1").Return(tt.request, tt.request != nil, tt.err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/api/v1/match-request/req1", nil)
+
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = req
+			ctx.Params = gin.Params{{Key: "request_id", Value: "req1"}}
+
+			handler.CancelMatchRequest(ctx)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "cancelled", response["status"])
+			}
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_CancelMatchRequest_Repeated(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	handler := &Handler{storage: store, logger: logrus.New()}
+	request := models.NewMatchRequest("player1", "repeat-cancel-game", nil)
+	require.NoError(t, store.StoreMatchRequest(context.Background(), request))
+	counter := metrics.MatchRequestCounter.WithLabelValues("repeat-cancel-game", "cancelled")
+	before := testutil.ToFloat64(counter)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(w)
+		ctx.Request, _ = http.NewRequest("DELETE", "/api/v1/match-request/"+request.ID, nil)
+		ctx.Params = gin.Params{{Key: "request_id", Value: request.ID}}
+
+		handler.CancelMatchRequest(ctx)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// Only the DELETE that cancelled the request is counted
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestHandler_GetMatchStatus_Cancelled(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	handler := &Handler{storage: store, logger: logrus.New()}
+	request := models.NewMatchRequest("player1", "test-game", nil)
+	require.NoError(t, store.StoreMatchRequest(context.Background(), request))
+	_, _, err := store.CancelMatchRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/match-status/"+request.ID, nil)
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Params = gin.Params{{Key: "request_id", Value: request.ID}}
+
+	handler.GetMatchStatus(ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.MatchStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.StatusCancelled, response.Status)
+}
+
 func TestHandler_ProcessMatchmaking_Success(t *testing.T) {
 	handler, mockStorage, _ := setupTestHandler()
 	
@@ -327,7 +733,7 @@ func TestHandler_ProcessMatchmaking_Success(t *testing.T) {
 		Teams: []models.Team{
 			{Name: "team1", Size: 2},
 		},
-		Rules: []models.Rule{{Field: "level", Min: &[]int{10}[0]}},
+		Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
 	}
 	
 	requests := []*models.MatchRequest{
@@ -345,11 +751,13 @@ func TestHandler_ProcessMatchmaking_Success(t *testing.T) {
 		},
 	}
 	
+	mockStorage.On("CleanupExpiredRequests", mock.Anything).Return(nil)
+	mockStorage.expectGameLock("test-game")
 	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return(config, nil)
 	mockStorage.On("GetGameQueue", mock.Anything, "test-game").Return(requests, nil)
-	mockStorage.On("StoreMatch", mock.Anything, mock.AnythingOfType("*models.Match")).Return(nil)
-	mockStorage.On("UpdateMatchRequestStatus", mock.Anything, "player1", models.StatusMatched).Return(nil)
-	mockStorage.On("UpdateMatchRequestStatus", mock.Anything, "player2", models.StatusMatched).Return(nil)
+	mockStorage.On("CommitMatch", mock.Anything, mock.AnythingOfType("*models.MultiTeamMatch"), mock.MatchedBy(func(statuses map[string]*models.MatchStatusResponse) bool {
+		return len(statuses) == 2 && statuses["req1"] != nil && statuses["req2"] != nil
+	})).Return(nil)
 	
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/api/v1/process-matchmaking/test-game", nil)
@@ -367,6 +775,8 @@ func TestHandler_ProcessMatchmaking_Success(t *testing.T) {
 func TestHandler_ProcessMatchmaking_GameConfigNotFound(t *testing.T) {
 	handler, mockStorage, _ := setupTestHandler()
 	
+	mockStorage.On("CleanupExpiredRequests", mock.Anything).Return(nil)
+	mockStorage.expectGameLock("test-game")
 	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return((*models.GameConfig)(nil), assert.AnError)
 	
 	w := httptest.NewRecorder()
@@ -383,7 +793,7 @@ func TestHandler_ProcessMatchmaking_GameConfigNotFound(t *testing.T) {
 }
 
 func TestHandler_AllocateSessions_Success(t *testing.T) {
-	handler, _, mockAllocator := setupTestHandler()
+	handler, mockStorage, mockAllocator := setupTestHandler()
 	
 	matches := []*models.Match{
 		{
@@ -403,6 +813,8 @@ func TestHandler_AllocateSessions_Success(t *testing.T) {
 	mockAllocator.On("AllocateSession", mock.MatchedBy(func(m *models.Match) bool {
 		return m.ID == "match1" && m.GameID == "test-game" && m.TeamName == "team1"
 	})).Return(session, nil)
+	mockStorage.On("GetMultiTeamMatch", mock.Anything, "match1").Return((*models.MultiTeamMatch)(nil), storage.ErrMatchNotFound)
+	mockStorage.On("UpdateMultiTeamMatch", mock.Anything, "match1", mock.Anything).Return((*models.MultiTeamMatch)(nil), storage.ErrMatchNotFound)
 	
 	body, _ := json.Marshal(matches)
 	w := httptest.NewRecorder()
@@ -422,6 +834,58 @@ func TestHandler_AllocateSessions_Success(t *testing.T) {
 	mockAllocator.AssertExpectations(t)
 }
 
+func TestHandler_ProcessMatchmaking_AllocatesInRegion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	allocator := &MockAllocator{}
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), allocator: allocator, logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+	}))
+	for _, playerID := range []string{"p1", "p2"} {
+		request := models.NewMatchRequest(playerID, "test-game", nil)
+		request.Latencies = map[string]int{"us-west": 20, "eu-west": 90}
+		require.NoError(t, store.StoreMatchRequest(ctx, request))
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/v1/process-matchmaking/test-game", nil)
+	c.Params = gin.Params{{Key: "game_id", Value: "test-game"}}
+	handler.ProcessMatchmaking(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Matches []struct {
+			MatchID string `json:"match_id"`
+			Region  string `json:"region"`
+		} `json:"matches"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Matches, 1)
+	assert.Equal(t, "us-west", response.Matches[0].Region)
+	matchID := response.Matches[0].MatchID
+
+	// A client that leaves the region out of the allocation body still gets the match's region
+	session := &models.GameSession{IP: "10.0.0.1", Port: 7777, ID: "session1"}
+	allocator.On("AllocateSession", mock.MatchedBy(func(m *models.Match) bool {
+		return m.ID == matchID && m.Region == "us-west"
+	})).Return(session, nil)
+	body, _ := json.Marshal([]*models.Match{{ID: matchID, GameID: "test-game", Players: []string{"p1"}, TeamName: "red"}})
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/v1/allocate-sessions/test-game", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "game_id", Value: "test-game"}}
+	handler.AllocateSessions(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	allocator.AssertExpectations(t)
+}
+
 func TestHandler_AllocateSessions_InvalidJSON(t *testing.T) {
 	handler, _, _ := setupTestHandler()
 	
@@ -459,4 +923,771 @@ func TestHandler_GetStats_Success(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.NotNil(t, response["storage"])
-} 
\ No newline at end of file
+} 
+func TestHandler_RunMatchmaking_EmptyQueue(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	config := &models.GameConfig{
+		GameID: "test-game",
+		Teams: []models.Team{
+			{Name: "team1", Size: 2},
+		},
+	}
+
+	mockStorage.expectGameLock("test-game")
+	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return(config, nil)
+	mockStorage.On("GetGameQueue", mock.Anything, "test-game").Return([]*models.MatchRequest{}, nil)
+
+	result, err := handler.RunMatchmaking(context.Background(), "test-game")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.QueueSize)
+	assert.Empty(t, result.Matches)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestHandler_RunMatchmaking_GameConfigNotFound(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	mockStorage.On("CleanupExpiredRequests", mock.Anything).Return(nil)
+	mockStorage.expectGameLock("test-game")
+	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return((*models.GameConfig)(nil), assert.AnError)
+
+	_, err := handler.RunMatchmaking(context.Background(), "test-game")
+
+	assert.ErrorIs(t, err, ErrGameConfigNotFound)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestHandler_RunMatchmaking_RequestAlreadyClaimed(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	config := &models.GameConfig{
+		GameID: "test-game",
+		Teams: []models.Team{
+			{Name: "team1", Size: 2},
+		},
+	}
+
+	requests := []*models.MatchRequest{
+		{ID: "req1", PlayerID: "player1", GameID: "test-game", Status: models.StatusPending},
+		{ID: "req2", PlayerID: "player2", GameID: "test-game", Status: models.StatusPending},
+	}
+
+	mockStorage.expectGameLock("test-game")
+	mockStorage.On("GetGameConfig", mock.Anything, "test-game").Return(config, nil)
+	mockStorage.On("GetGameQueue", mock.Anything, "test-game").Return(requests, nil)
+	mockStorage.On("CommitMatch", mock.Anything, mock.AnythingOfType("*models.MultiTeamMatch"), mock.Anything).
+		Return(fmt.Errorf("%w: req2 is matched", storage.ErrRequestClaimed))
+
+	result, err := handler.RunMatchmaking(context.Background(), "test-game")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.QueueSize)
+	assert.Empty(t, result.Matches)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestHandler_ProcessMatchmaking_GameLocked(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+
+	mockStorage.On("AcquireGameLock", mock.Anything, "test-game", mock.AnythingOfType("string"), gameLockTTL).Return(false, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/process-matchmaking/test-game", nil)
+
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = req
+	ctx.Params = gin.Params{{Key: "game_id", Value: "test-game"}}
+
+	handler.ProcessMatchmaking(ctx)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "GetGameQueue", mock.Anything, "test-game")
+	mockStorage.AssertNotCalled(t, "CleanupExpiredRequests", mock.Anything)
+}
+
+func TestHandler_RunMatchmaking_SharesLockAcrossReplicas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "team1", Size: 1}, {Name: "team2", Size: 1}},
+	}))
+	for i := 0; i < 20; i++ {
+		require.NoError(t, store.StoreMatchRequest(ctx, models.NewMatchRequest(fmt.Sprintf("player%d", i), "test-game", nil)))
+	}
+
+	// Two replicas share the same storage and race to process the game
+	replicas := []*Handler{
+		{storage: store, matchmaker: matchmaker.NewMatchmaker(), ruleEngine: engine.NewRuleEngine(), logger: logrus.New()},
+		{storage: store, matchmaker: matchmaker.NewMatchmaker(), ruleEngine: engine.NewRuleEngine(), logger: logrus.New()},
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	matched := make(map[string]int)
+	for _, h := range replicas {
+		wg.Add(1)
+		go func(h *Handler) {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				result, err := h.RunMatchmaking(ctx, "test-game")
+				if errors.Is(err, ErrGameLocked) {
+					continue
+				}
+				require.NoError(t, err)
+				mu.Lock()
+				for _, match := range result.Matches {
+					for _, playerID := range h.matchmaker.FlattenTeams(match.Teams) {
+						matched[playerID]++
+					}
+				}
+				mu.Unlock()
+			}
+		}(h)
+	}
+	wg.Wait()
+
+	assert.Len(t, matched, 20)
+	for playerID, count := range matched {
+		assert.Equal(t, 1, count, "player %s matched more than once", playerID)
+	}
+}
+
+// blockingConfigStorage holds the first GetGameConfig call until release is closed, so a matchmaking
+// pass can be kept running while it holds the game lock
+type blockingConfigStorage struct {
+	storage.Storage
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingConfigStorage) GetGameConfig(ctx context.Context, gameID string) (*models.GameConfig, error) {
+	s.once.Do(func() {
+		close(s.entered)
+		<-s.release
+	})
+	return s.Storage.GetGameConfig(ctx, gameID)
+}
+
+func TestHandler_RunMatchmaking_ExcludesConcurrentPassesInOneReplica(t *testing.T) {
+	memory := storage.NewMemoryStorage()
+	ctx := context.Background()
+	require.NoError(t, memory.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "team1", Size: 1}, {Name: "team2", Size: 1}},
+	}))
+
+	store := &blockingConfigStorage{Storage: memory, entered: make(chan struct{}), release: make(chan struct{})}
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	// The first pass takes the lock and waits inside it
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := handler.RunMatchmaking(ctx, "test-game")
+		firstErr <- err
+	}()
+	<-store.entered
+
+	// A second pass on the same handler, as from the scheduler and an API call, must not get the lock
+	_, err := handler.RunMatchmaking(ctx, "test-game")
+	assert.ErrorIs(t, err, ErrGameLocked)
+
+	close(store.release)
+	require.NoError(t, <-firstErr)
+
+	// The first pass released the lock when it finished
+	_, err = handler.RunMatchmaking(ctx, "test-game")
+	assert.NoError(t, err)
+}
+
+func TestHandler_RunMatchmaking_PartyTicket(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+	}))
+	party := models.NewMatchRequest("leader", "test-game", nil)
+	party.Members = []models.PartyMember{{PlayerID: "friend"}}
+	require.NoError(t, store.StoreMatchRequest(ctx, party))
+	for _, playerID := range []string{"solo1", "solo2"} {
+		require.NoError(t, store.StoreMatchRequest(ctx, models.NewMatchRequest(playerID, "test-game", nil)))
+	}
+
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+
+	// The party's single ticket resolves for both members, who share a team
+	status, err := store.GetMatchStatus(ctx, party.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusMatched, status.Status)
+	assert.ElementsMatch(t, []string{"leader", "friend"}, status.Players)
+	assert.Len(t, status.AllPlayers, 4)
+}
+
+
+func TestHandler_RunMatchmaking_Roles(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "duo", Size: 2, Roles: map[string]int{"tank": 1, "healer": 1}}},
+	}))
+	tank := models.NewMatchRequest("tank-player", "test-game", map[string]interface{}{"roles": []interface{}{"tank"}})
+	flex := models.NewMatchRequest("flex-player", "test-game", map[string]interface{}{"roles": []interface{}{"tank", "healer"}})
+	require.NoError(t, store.StoreMatchRequest(ctx, tank))
+	require.NoError(t, store.StoreMatchRequest(ctx, flex))
+
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+
+	status, err := store.GetMatchStatus(ctx, flex.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "healer", status.Role)
+	assert.Equal(t, map[string]string{"tank-player": "tank", "flex-player": "healer"}, status.Roles)
+}
+
+func TestHandler_GetMatchStatus_PartyMemberRole(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "duo", Size: 2, Roles: map[string]int{"tank": 1, "healer": 1}}},
+	}))
+	party := models.NewMatchRequest("leader", "test-game", map[string]interface{}{"roles": []interface{}{"tank"}})
+	party.Members = []models.PartyMember{{PlayerID: "friend", Metadata: map[string]interface{}{"roles": []interface{}{"healer"}}}}
+	require.NoError(t, store.StoreMatchRequest(ctx, party))
+	_, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+
+	get := func(query string) (int, models.MatchStatusResponse) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/api/v1/match-status/"+party.ID+query, nil)
+		c.Params = gin.Params{{Key: "request_id", Value: party.ID}}
+		handler.GetMatchStatus(c)
+		var response models.MatchStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	// The shared status reports the leader's role unless a member asks for their own
+	code, status := get("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "tank", status.Role)
+	code, status = get("?player_id=friend")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healer", status.Role)
+	assert.Equal(t, map[string]string{"leader": "tank", "friend": "healer"}, status.Roles)
+	code, _ = get("?player_id=stranger")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestHandler_RunMatchmaking_Region(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID:  "test-game",
+		Teams:   []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+		Latency: &models.LatencyRule{MaxPing: 100},
+	}))
+	west := models.NewMatchRequest("west-player", "test-game", nil)
+	west.Latencies = map[string]int{"us-west": 20, "us-east": 70}
+	east := models.NewMatchRequest("east-player", "test-game", nil)
+	east.Latencies = map[string]int{"us-west": 90, "us-east": 30}
+	require.NoError(t, store.StoreMatchRequest(ctx, west))
+	require.NoError(t, store.StoreMatchRequest(ctx, east))
+
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	assert.Equal(t, "us-east", result.Matches[0].Region)
+
+	status, err := store.GetMatchStatus(ctx, west.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "us-east", status.Region)
+}
+
+func TestHandler_CreateBackfillRequest(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+	}))
+	match := &models.MultiTeamMatch{
+		ID:        "match1",
+		GameID:    "test-game",
+		Teams:     map[string][]string{"red": {"p1", "p2"}, "blue": {"p3", "p4"}},
+		CreatedAt: time.Now(),
+		Session:   &models.GameSession{IP: "127.0.0.1", Port: 7777, ID: "session1"},
+	}
+	require.NoError(t, store.StoreMultiTeamMatch(ctx, match))
+
+	createBackfill := func(matchID string, body BackfillRequestRequest) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/matches/"+matchID+"/backfill", bytes.NewBuffer(data))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "match_id", Value: matchID}}
+		handler.CreateBackfillRequest(c)
+		return w
+	}
+
+	t.Run("Unknown match", func(t *testing.T) {
+		w := createBackfill("missing", BackfillRequestRequest{TeamName: "red", Slots: 1})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Unknown team", func(t *testing.T) {
+		w := createBackfill("match1", BackfillRequestRequest{TeamName: "green", Slots: 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Too many slots", func(t *testing.T) {
+		w := createBackfill("match1", BackfillRequestRequest{TeamName: "red", Slots: 3})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("More slots than departed players", func(t *testing.T) {
+		w := createBackfill("match1", BackfillRequestRequest{TeamName: "red", Slots: 1})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = createBackfill("match1", BackfillRequestRequest{TeamName: "red", Slots: 2, Departed: []string{"p2"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Departed player not on the team", func(t *testing.T) {
+		w := createBackfill("match1", BackfillRequestRequest{TeamName: "red", Slots: 1, Departed: []string{"p3"}})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fills from the queue", func(t *testing.T) {
+		w := createBackfill("match1", BackfillRequestRequest{TeamName: "red", Slots: 1, Departed: []string{"p2"}})
+		require.Equal(t, http.StatusCreated, w.Code)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		backfillID := response["request_id"].(string)
+
+		replacement := models.NewMatchRequest("p5", "test-game", nil)
+		require.NoError(t, store.StoreMatchRequest(ctx, replacement))
+
+		result, err := handler.RunMatchmaking(ctx, "test-game")
+		require.NoError(t, err)
+		assert.Empty(t, result.Matches)
+		require.Len(t, result.Backfills, 1)
+
+		status, err := store.GetMatchStatus(ctx, replacement.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusMatched, status.Status)
+		assert.Equal(t, "match1", status.MatchID)
+		assert.Equal(t, "red", status.TeamName)
+		assert.Equal(t, match.Session, status.Session)
+		assert.ElementsMatch(t, []string{"p1", "p5"}, status.Players)
+		assert.ElementsMatch(t, []string{"p1", "p5", "p3", "p4"}, status.AllPlayers)
+
+		status, err = store.GetMatchStatus(ctx, backfillID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusMatched, status.Status)
+		assert.Equal(t, []string{"p5"}, status.Players)
+
+		// The replacement takes the departed player's seat; the team stays at its size
+		stored, err := store.GetMultiTeamMatch(ctx, "match1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"p1", "p5"}, stored.Teams["red"])
+		assert.Len(t, stored.Teams["red"], 2)
+	})
+}
+
+func TestHandler_Backfill_AllocatedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	allocator := &MockAllocator{}
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), allocator: allocator, logger: logrus.New()}
+
+	// Two players who have waited past the fill deadline launch a short-handed 1v1
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID:       "test-game",
+		Teams:        []models.Team{{Name: "red", MinSize: 1, MaxSize: 2}, {Name: "blue", MinSize: 1, MaxSize: 2}},
+		FillDeadline: 30,
+	}))
+	p1 := models.NewMatchRequest("p1", "test-game", nil)
+	p1.CreatedAt = time.Now().Add(-time.Minute)
+	p2 := models.NewMatchRequest("p2", "test-game", nil)
+	p2.CreatedAt = time.Now().Add(-time.Minute)
+	require.NoError(t, store.StoreMatchRequest(ctx, p1))
+	require.NoError(t, store.StoreMatchRequest(ctx, p2))
+
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	match := result.Matches[0]
+
+	// Allocate a server for the match the way a client does, from the process-matchmaking response
+	session := &models.GameSession{IP: "10.0.0.1", Port: 7777, ID: "session1"}
+	allocator.On("AllocateSession", mock.MatchedBy(func(m *models.Match) bool { return m.ID == match.ID })).Return(session, nil)
+	body, _ := json.Marshal([]*models.Match{{ID: match.ID, GameID: "test-game", Players: []string{"p1", "p2"}, TeamName: "red"}})
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("POST", "/api/v1/allocate-sessions/test-game", bytes.NewBuffer(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "game_id", Value: "test-game"}}
+	handler.AllocateSessions(c)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	status, err := store.GetMatchStatus(ctx, p1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session, status.Session)
+
+	// A player backfilled later gets the same session, and the existing players see the new roster
+	require.NoError(t, store.StoreMatchRequest(ctx, models.NewBackfillRequest(match, "red", 1, nil)))
+	p3 := models.NewMatchRequest("p3", "test-game", nil)
+	require.NoError(t, store.StoreMatchRequest(ctx, p3))
+
+	result, err = handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Backfills, 1)
+
+	status, err = store.GetMatchStatus(ctx, p3.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session, status.Session)
+
+	status, err = store.GetMatchStatus(ctx, p1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1", "p3"}, status.Players)
+	assert.ElementsMatch(t, []string{"p1", "p2", "p3"}, status.AllPlayers)
+	allocator.AssertExpectations(t)
+}
+
+func TestHandler_Backfill_Roles(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	roles := map[string]int{"tank": 1, "dps": 1}
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID: "test-game",
+		Teams:  []models.Team{{Name: "red", Size: 2, Roles: roles}, {Name: "blue", Size: 2, Roles: roles}},
+	}))
+	match := &models.MultiTeamMatch{
+		ID:        "match1",
+		GameID:    "test-game",
+		Teams:     map[string][]string{"red": {"p1", "p2"}, "blue": {"p3", "p4"}},
+		Roles:     map[string]string{"p1": "tank", "p2": "dps", "p3": "tank", "p4": "dps"},
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, store.StoreMultiTeamMatch(ctx, match))
+	require.NoError(t, store.StoreMatchRequest(ctx, models.NewBackfillRequest(match, "red", 1, []string{"p2"})))
+
+	// The departed dps leaves the only open slot; the longer-waiting tank can't take it
+	tank := models.NewMatchRequest("tank", "test-game", map[string]interface{}{"roles": []interface{}{"tank"}})
+	tank.CreatedAt = time.Now().Add(-time.Minute)
+	dps := models.NewMatchRequest("dps", "test-game", map[string]interface{}{"roles": []interface{}{"dps"}})
+	require.NoError(t, store.StoreMatchRequest(ctx, tank))
+	require.NoError(t, store.StoreMatchRequest(ctx, dps))
+
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Backfills, 1)
+
+	status, err := store.GetMatchStatus(ctx, dps.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "dps", status.Role)
+	assert.Equal(t, "dps", status.Roles["dps"])
+
+	stored, err := store.GetMultiTeamMatch(ctx, "match1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p1", "dps"}, stored.Teams["red"])
+	assert.Equal(t, map[string]string{"p1": "tank", "dps": "dps", "p3": "tank", "p4": "dps"}, stored.Roles)
+
+	request, err := store.GetMatchRequest(ctx, tank.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, request.Status)
+}
+
+func TestHandler_ReadyCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// setup queues two players for a 1v1 with a ready check and forms their match
+	setup := func(t *testing.T) (*Handler, *storage.MemoryStorage, *models.MultiTeamMatch, map[string]*models.MatchRequest) {
+		store := storage.NewMemoryStorage()
+		ctx := context.Background()
+		handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+		require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+			GameID:       "test-game",
+			Teams:        []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+			AcceptWindow: 30,
+		}))
+		requests := make(map[string]*models.MatchRequest)
+		for _, playerID := range []string{"p1", "p2"} {
+			request := models.NewMatchRequest(playerID, "test-game", nil)
+			request.CreatedAt = time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+			require.NoError(t, store.StoreMatchRequest(ctx, request))
+			requests[playerID] = request
+		}
+
+		result, err := handler.RunMatchmaking(ctx, "test-game")
+		require.NoError(t, err)
+		require.Len(t, result.Matches, 1)
+		return handler, store, result.Matches[0], requests
+	}
+
+	respond := func(handler *Handler, action func(*gin.Context), matchID, playerID string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(&ReadyCheckRequest{PlayerID: playerID})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/matches/"+matchID, bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "match_id", Value: matchID}}
+		action(c)
+		return w
+	}
+
+	status := func(t *testing.T, store *storage.MemoryStorage, requestID string) models.MatchStatus {
+		if status, err := store.GetMatchStatus(context.Background(), requestID); err == nil {
+			return status.Status
+		}
+		request, err := store.GetMatchRequest(context.Background(), requestID)
+		require.NoError(t, err)
+		return request.Status
+	}
+
+	t.Run("Match awaits acceptance", func(t *testing.T) {
+		_, store, match, requests := setup(t)
+
+		assert.Equal(t, models.StatusAwaitingAccept, match.Status)
+		got, err := store.GetMatchStatus(context.Background(), requests["p1"].ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusAwaitingAccept, got.Status)
+		assert.NotEmpty(t, got.AcceptBy)
+	})
+
+	t.Run("Every player accepts", func(t *testing.T) {
+		handler, store, match, requests := setup(t)
+
+		w := respond(handler, handler.AcceptMatch, match.ID, "p1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.StatusAwaitingAccept, status(t, store, requests["p1"].ID))
+
+		w = respond(handler, handler.AcceptMatch, match.ID, "p2")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, models.StatusMatched, status(t, store, requests["p1"].ID))
+		assert.Equal(t, models.StatusMatched, status(t, store, requests["p2"].ID))
+
+		w = respond(handler, handler.DeclineMatch, match.ID, "p2")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Decline requeues players who accepted", func(t *testing.T) {
+		handler, store, match, requests := setup(t)
+
+		require.Equal(t, http.StatusOK, respond(handler, handler.AcceptMatch, match.ID, "p1").Code)
+		require.Equal(t, http.StatusOK, respond(handler, handler.DeclineMatch, match.ID, "p2").Code)
+
+		assert.Equal(t, models.StatusPending, status(t, store, requests["p1"].ID))
+		assert.Equal(t, models.StatusFailed, status(t, store, requests["p2"].ID))
+		queue, err := store.GetGameQueue(context.Background(), "test-game")
+		require.NoError(t, err)
+		require.Len(t, queue, 1)
+		assert.Equal(t, requests["p1"].ID, queue[0].ID)
+		assert.True(t, requests["p1"].CreatedAt.Equal(queue[0].CreatedAt))
+	})
+
+	t.Run("Timeout dissolves the match", func(t *testing.T) {
+		handler, store, match, requests := setup(t)
+		ctx := context.Background()
+
+		require.Equal(t, http.StatusOK, respond(handler, handler.AcceptMatch, match.ID, "p1").Code)
+		_, err := store.UpdateMultiTeamMatch(ctx, match.ID, func(match *models.MultiTeamMatch) error {
+			acceptBy := time.Now().Add(-time.Second)
+			match.AcceptBy = &acceptBy
+			return nil
+		})
+		require.NoError(t, err)
+
+		w := respond(handler, handler.AcceptMatch, match.ID, "p2")
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, models.StatusPending, status(t, store, requests["p1"].ID))
+		assert.Equal(t, models.StatusFailed, status(t, store, requests["p2"].ID))
+	})
+
+	t.Run("Invalid responses", func(t *testing.T) {
+		handler, _, match, _ := setup(t)
+
+		assert.Equal(t, http.StatusNotFound, respond(handler, handler.AcceptMatch, "missing", "p1").Code)
+		assert.Equal(t, http.StatusBadRequest, respond(handler, handler.AcceptMatch, match.ID, "stranger").Code)
+		assert.Equal(t, http.StatusBadRequest, respond(handler, handler.DeclineMatch, match.ID, "").Code)
+	})
+}
+
+func TestHandler_RunMatchmaking_SweepsExpiredReadyChecks(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID:       "test-game",
+		Teams:        []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+		AcceptWindow: 30,
+	}))
+	p1 := models.NewMatchRequest("p1", "test-game", nil)
+	p2 := models.NewMatchRequest("p2", "test-game", nil)
+	require.NoError(t, store.StoreMatchRequest(ctx, p1))
+	require.NoError(t, store.StoreMatchRequest(ctx, p2))
+
+	// p1 accepted but nobody has polled the match since its window closed
+	acceptBy := time.Now().Add(-time.Second)
+	require.NoError(t, store.CommitMatch(ctx, &models.MultiTeamMatch{
+		ID:         "match1",
+		GameID:     "test-game",
+		Teams:      map[string][]string{"red": {"p1"}, "blue": {"p2"}},
+		Status:     models.StatusAwaitingAccept,
+		AcceptBy:   &acceptBy,
+		Accepted:   []string{"p1"},
+		RequestIDs: []string{p1.ID, p2.ID},
+	}, map[string]*models.MatchStatusResponse{
+		p1.ID: {Status: models.StatusAwaitingAccept, MatchID: "match1"},
+		p2.ID: {Status: models.StatusAwaitingAccept, MatchID: "match1"},
+	}))
+
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+
+	// The pass dissolves the match and sees p1's requeued ticket
+	assert.Equal(t, 1, result.QueueSize)
+	match, err := store.GetMultiTeamMatch(ctx, "match1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, match.Status)
+	request, err := store.GetMatchRequest(ctx, p1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, request.Status)
+	status, err := store.GetMatchStatus(ctx, p2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusFailed, status.Status)
+	expired, err := store.ExpiredReadyChecks(ctx, "test-game", time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+}
+
+func TestHandler_SweepReadyChecks_RetriesFailedExpiry(t *testing.T) {
+	handler, mockStorage, _ := setupTestHandler()
+	ctx := context.Background()
+
+	// Updating match1 fails transiently; match2 was accepted in time and only leaves the index
+	mockStorage.On("ExpiredReadyChecks", mock.Anything, "test-game", mock.Anything).Return([]string{"match1", "match2"}, nil)
+	mockStorage.On("UpdateMultiTeamMatch", mock.Anything, "match1", mock.Anything).
+		Return((*models.MultiTeamMatch)(nil), errors.New("connection reset"))
+	mockStorage.On("UpdateMultiTeamMatch", mock.Anything, "match2", mock.Anything).
+		Return((*models.MultiTeamMatch)(nil), ErrMatchNotAwaitingAccept)
+	mockStorage.On("RemoveReadyCheck", mock.Anything, "test-game", "match2").Return(nil)
+
+	handler.sweepReadyChecks(ctx, "test-game")
+
+	mockStorage.AssertExpectations(t)
+	mockStorage.AssertNotCalled(t, "RemoveReadyCheck", mock.Anything, "test-game", "match1")
+}
+
+func TestHandler_ReadyCheck_OutlivesTicketTTL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mr := miniredis.RunT(t)
+	store := storage.NewRedisStorage(mr.Addr(), "", 0)
+	defer store.Close()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	// Tickets live 5 seconds without a heartbeat but the accept window is 30 seconds
+	require.NoError(t, store.StoreGameConfig(ctx, &models.GameConfig{
+		GameID:       "test-game",
+		Teams:        []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+		TicketTTL:    5,
+		AcceptWindow: 30,
+	}))
+	requests := make(map[string]*models.MatchRequest)
+	for _, playerID := range []string{"p1", "p2"} {
+		request := models.NewMatchRequest(playerID, "test-game", nil)
+		request.TTL = 5
+		require.NoError(t, store.StoreMatchRequest(ctx, request))
+		requests[playerID] = request
+	}
+	result, err := handler.RunMatchmaking(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, result.Matches, 1)
+	matchID := result.Matches[0].ID
+
+	// Players answer after their tickets would have expired
+	mr.FastForward(20 * time.Second)
+	for _, response := range []struct {
+		action   func(*gin.Context)
+		playerID string
+	}{{handler.AcceptMatch, "p1"}, {handler.DeclineMatch, "p2"}} {
+		body, _ := json.Marshal(&ReadyCheckRequest{PlayerID: response.playerID})
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("POST", "/api/v1/matches/"+matchID, bytes.NewBuffer(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "match_id", Value: matchID}}
+		response.action(c)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// The player who accepted is back in the queue
+	queue, err := store.GetGameQueue(ctx, "test-game")
+	require.NoError(t, err)
+	require.Len(t, queue, 1)
+	assert.Equal(t, requests["p1"].ID, queue[0].ID)
+	assert.Equal(t, models.StatusPending, queue[0].Status)
+}
+
+func TestHandler_GetMatchStatus_ReadyCheckTimeout(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	handler := &Handler{storage: store, matchmaker: matchmaker.NewMatchmaker(), logger: logrus.New()}
+
+	request := models.NewMatchRequest("p1", "test-game", nil)
+	require.NoError(t, store.StoreMatchRequest(ctx, request))
+	acceptBy := time.Now().Add(-time.Second)
+	match := &models.MultiTeamMatch{
+		ID:         "match1",
+		GameID:     "test-game",
+		Teams:      map[string][]string{"red": {"p1"}},
+		Status:     models.StatusAwaitingAccept,
+		AcceptBy:   &acceptBy,
+		Accepted:   []string{"p1"},
+		RequestIDs: []string{request.ID},
+	}
+	require.NoError(t, store.CommitMatch(ctx, match, map[string]*models.MatchStatusResponse{
+		request.ID: {Status: models.StatusAwaitingAccept, MatchID: "match1", AcceptBy: acceptBy.Format(time.RFC3339)},
+	}))
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api/v1/match-status/"+request.ID, nil)
+	c.Params = gin.Params{{Key: "request_id", Value: request.ID}}
+	handler.GetMatchStatus(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.MatchStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.StatusPending, response.Status)
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is one step of a field path, either a map key or an array index
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseFieldPath splits a rule field into its segments. Keys are separated by dots and array
+// indices are written in brackets, as in stats.mmr.ranked or loadout[0].name.
+func parseFieldPath(field string) ([]pathSegment, error) {
+	if field == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	var segments []pathSegment
+	for i := 0; i < len(field); {
+		switch field[i] {
+		case '[':
+			end := strings.IndexByte(field[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed '[' at offset %d", i)
+			}
+			digits := field[i+1 : i+end]
+			index, err := strconv.Atoi(digits)
+			if err != nil || strings.TrimLeft(digits, "0123456789") != "" {
+				return nil, fmt.Errorf("invalid array index '%s' at offset %d", digits, i)
+			}
+			if len(segments) == 0 {
+				return nil, fmt.Errorf("path must start with a key")
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			i += end + 1
+			if i < len(field) && field[i] != '.' && field[i] != '[' {
+				return nil, fmt.Errorf("unexpected '%c' at offset %d", field[i], i)
+			}
+		case ']':
+			return nil, fmt.Errorf("unexpected ']' at offset %d", i)
+		default:
+			if field[i] == '.' {
+				if len(segments) == 0 {
+					return nil, fmt.Errorf("empty key at offset %d", i)
+				}
+				i++
+			}
+			end := strings.IndexAny(field[i:], ".[]")
+			if end < 0 {
+				end = len(field) - i
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key at offset %d", i)
+			}
+			segments = append(segments, pathSegment{key: field[i : i+end]})
+			i += end
+		}
+	}
+
+	return segments, nil
+}
+
+// ValidateFieldPath checks that a rule field is a well-formed path
+func (re *RuleEngine) ValidateFieldPath(field string) error {
+	_, err := parseFieldPath(field)
+	return err
+}
+
+// fieldPath returns the segments of a field path, parsing it the first time it is resolved
+func (re *RuleEngine) fieldPath(field string) ([]pathSegment, error) {
+	re.mu.RLock()
+	segments, ok := re.paths[field]
+	re.mu.RUnlock()
+	if ok {
+		return segments, nil
+	}
+
+	segments, err := parseFieldPath(field)
+	if err != nil {
+		return nil, err
+	}
+	re.mu.Lock()
+	if re.paths == nil || len(re.paths) >= maxCachedPaths {
+		re.paths = make(map[string][]pathSegment)
+	}
+	re.paths[field] = segments
+	re.mu.Unlock()
+	return segments, nil
+}
+
+// FieldValue resolves a field path against player metadata, reporting false if the path is
+// malformed or any segment is missing
+func (re *RuleEngine) FieldValue(metadata map[string]interface{}, field string) (interface{}, bool) {
+	segments, err := re.fieldPath(field)
+	if err != nil {
+		return nil, false
+	}
+
+	var value interface{} = metadata
+	for _, segment := range segments {
+		var ok bool
+		if segment.isIndex {
+			value, ok = re.indexValue(value, segment.index)
+		} else {
+			value, ok = re.keyValue(value, segment.key)
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// keyValue returns the value under key when value is an object
+func (re *RuleEngine) keyValue(value interface{}, key string) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result, ok := v[key]
+		return result, ok
+	case map[string]string:
+		result, ok := v[key]
+		return result, ok
+	default:
+		return nil, false
+	}
+}
+
+// indexValue returns the element at index when value is an array
+func (re *RuleEngine) indexValue(value interface{}, index int) (interface{}, bool) {
+	switch v := value.(type) {
+	case []interface{}:
+		if index < len(v) {
+			return v[index], true
+		}
+	case []string:
+		if index < len(v) {
+			return v[index], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+)
+
+func TestRuleEngine_ValidateFieldPath(t *testing.T) {
+	engine := NewRuleEngine()
+
+	tests := []struct {
+		field   string
+		wantErr bool
+	}{
+		{field: "level", wantErr: false},
+		{field: "stats.mmr.ranked", wantErr: false},
+		{field: "loadout[0]", wantErr: false},
+		{field: "loadout[0].name", wantErr: false},
+		{field: "grid[1][2]", wantErr: false},
+		{field: "", wantErr: true},
+		{field: ".stats", wantErr: true},
+		{field: "stats.", wantErr: true},
+		{field: "stats..mmr", wantErr: true},
+		{field: "[0]", wantErr: true},
+		{field: "loadout[]", wantErr: true},
+		{field: "loadout[-1]", wantErr: true},
+		{field: "loadout[+1]", wantErr: true},
+		{field: "loadout[x]", wantErr: true},
+		{field: "loadout[0", wantErr: true},
+		{field: "loadout]", wantErr: true},
+		{field: "loadout[0]name", wantErr: true},
+		{field: "loadout.[0]", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			err := engine.ValidateFieldPath(tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldPath(%q) error = %v, wantErr %v", tt.field, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuleEngine_FieldValue(t *testing.T) {
+	engine := NewRuleEngine()
+	metadata := map[string]interface{}{
+		"level": 25,
+		"stats": map[string]interface{}{
+			"mmr": map[string]interface{}{"ranked": 1800.0},
+		},
+		"loadout": []interface{}{
+			map[string]interface{}{"name": "sniper"},
+			"pistol",
+		},
+		"roles": []string{"tank", "dps"},
+	}
+
+	tests := []struct {
+		field  string
+		want   interface{}
+		wantOK bool
+	}{
+		{field: "level", want: 25, wantOK: true},
+		{field: "stats.mmr.ranked", want: 1800.0, wantOK: true},
+		{field: "loadout[0].name", want: "sniper", wantOK: true},
+		{field: "loadout[1]", want: "pistol", wantOK: true},
+		{field: "roles[1]", want: "dps", wantOK: true},
+		{field: "stats.mmr.casual", wantOK: false},
+		{field: "loadout[2]", wantOK: false},
+		{field: "level.value", wantOK: false},
+		{field: "stats[0]", wantOK: false},
+		{field: "stats..mmr", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			got, ok := engine.FieldValue(metadata, tt.field)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("FieldValue(%q) = %v, %v, want %v, %v", tt.field, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRuleEngine_FieldPathAcrossRules(t *testing.T) {
+	engine := NewRuleEngine()
+	player := func(mmr float64, weapon string) *models.MatchRequest {
+		return &models.MatchRequest{Metadata: map[string]interface{}{
+			"stats":   map[string]interface{}{"mmr": map[string]interface{}{"ranked": mmr}},
+			"loadout": []interface{}{weapon},
+		}}
+	}
+	a, b := player(1800, "sniper"), player(1900, "rifle")
+
+	rules := []models.Rule{
+		{Field: "stats.mmr.ranked", Min: &[]float64{1500}[0], Max: &[]float64{2000}[0], MaxSpread: &[]float64{150}[0], Strict: true},
+		{Field: "loadout[0]", Contains: &[]string{"sniper"}[0], Strict: true},
+	}
+	if ok, violations := engine.EvaluatePlayer(a, rules, 0); !ok {
+		t.Errorf("EvaluatePlayer() failed: %v", violations)
+	}
+	if ok, _ := engine.EvaluatePlayer(b, rules, 0); ok {
+		t.Errorf("EvaluatePlayer() passed a player without the item")
+	}
+	if ok, violations := engine.EvaluateGroup([]*models.MatchRequest{a, b}, rules, 0); !ok {
+		t.Errorf("EvaluateGroup() failed: %v", violations)
+	}
+	if distance := engine.Distance(a, b, rules, 0); distance != 100.0/150 {
+		t.Errorf("Distance() = %v, want %v", distance, 100.0/150)
+	}
+
+	teamRules := []models.TeamRule{{Field: "stats.mmr.ranked", Aggregate: models.AggregateAvg, MaxDiff: &[]float64{50}[0]}}
+	if ok, _ := engine.EvaluateTeams([][]*models.MatchRequest{{a}, {b}}, teamRules, 0); ok {
+		t.Errorf("EvaluateTeams() passed teams 100 apart with max_diff 50")
+	}
+}
+
+func TestRuleEngine_FieldPathCache(t *testing.T) {
+	engine := NewRuleEngine()
+	metadata := map[string]interface{}{"stats": map[string]interface{}{"mmr": 1800}}
+
+	// Validating a path doesn't cache it
+	if err := engine.ValidateFieldPath("stats.mmr"); err != nil {
+		t.Fatalf("ValidateFieldPath() error = %v", err)
+	}
+	if len(engine.paths) != 0 {
+		t.Errorf("paths cached after validation = %d, want 0", len(engine.paths))
+	}
+
+	// Each well-formed path is parsed once and reused
+	for i := 0; i < 2; i++ {
+		if value, ok := engine.FieldValue(metadata, "stats.mmr"); !ok || value != 1800 {
+			t.Errorf("FieldValue() = %v, %v, want 1800, true", value, ok)
+		}
+		if _, ok := engine.FieldValue(metadata, "stats..mmr"); ok {
+			t.Errorf("FieldValue() resolved a malformed path")
+		}
+	}
+	if len(engine.paths) != 1 {
+		t.Errorf("paths cached = %d, want 1", len(engine.paths))
+	}
+
+	// The cache never grows past its bound
+	for i := 0; i < maxCachedPaths+10; i++ {
+		engine.FieldValue(metadata, fmt.Sprintf("field%d", i))
+	}
+	if len(engine.paths) > maxCachedPaths {
+		t.Errorf("paths cached = %d, want at most %d", len(engine.paths), maxCachedPaths)
+	}
+}
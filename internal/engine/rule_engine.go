@@ -2,103 +2,236 @@ package engine
 
 import (
 	"fmt"
+	"math"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mm-rules/matchmaking/internal/models"
 )
 
+// maxCachedPaths bounds the parsed field paths a RuleEngine caches; the cache starts over once full
+const maxCachedPaths = 1024
+
 // RuleEngine handles the evaluation of matchmaking rules
-type RuleEngine struct{}
+type RuleEngine struct {
+	mu       sync.RWMutex
+	patterns map[string]*regexp.Regexp // rule regexes compiled with their anchors when their config is validated, by pattern
+	paths    map[string][]pathSegment  // parsed field paths, by field
+}
 
 // NewRuleEngine creates a new rule engine instance
 func NewRuleEngine() *RuleEngine {
-	return &RuleEngine{}
+	return &RuleEngine{
+		patterns: make(map[string]*regexp.Regexp),
+		paths:    make(map[string][]pathSegment),
+	}
 }
 
-// EvaluatePlayer evaluates a single player against a set of rules
+// EvaluatePlayer evaluates a single player against a set of rules, which must all pass. For a party ticket
+// every member must pass each rule, unless the rule sets party_aggregate.
 func (re *RuleEngine) EvaluatePlayer(player *models.MatchRequest, rules []models.Rule, elapsedTime time.Duration) (bool, []string) {
 	var violations []string
 
-	// Sort rules by priority (higher priority first)
+	for _, rule := range re.byPriority(rules) {
+		if !re.evaluateTicketRule(player, rule, elapsedTime) {
+			violation := fmt.Sprintf("Rule '%s' failed", re.describeRule(rule))
+			violations = append(violations, violation)
+		}
+	}
+
+	return len(violations) == 0, violations
+}
+
+// byPriority returns a copy of rules sorted by priority, higher priority first
+func (re *RuleEngine) byPriority(rules []models.Rule) []models.Rule {
 	sortedRules := make([]models.Rule, len(rules))
 	copy(sortedRules, rules)
-	sort.Slice(sortedRules, func(i, j int) bool {
+	sort.SliceStable(sortedRules, func(i, j int) bool {
 		return sortedRules[i].Priority > sortedRules[j].Priority
 	})
+	return sortedRules
+}
 
-	for _, rule := range sortedRules {
-		if !re.evaluateRule(player, rule, elapsedTime) {
-			violation := fmt.Sprintf("Rule '%s' failed", rule.Field)
-			violations = append(violations, violation)
-		}
+// describeRule names a rule in violations: its field, or its group and the rules it combines
+func (re *RuleEngine) describeRule(rule models.Rule) string {
+	var op string
+	var children []models.Rule
+	switch {
+	case rule.Not != nil:
+		op, children = "not", []models.Rule{*rule.Not}
+	case rule.Any != nil:
+		op, children = "any", rule.Any
+	case rule.All != nil:
+		op, children = "all", rule.All
+	default:
+		return rule.Field
 	}
 
-	return len(violations) == 0, violations
+	names := make([]string, len(children))
+	for i, child := range children {
+		names[i] = re.describeRule(child)
+	}
+	return op + "(" + strings.Join(names, ", ") + ")"
 }
 
-// evaluateRule evaluates a single rule against a player
-func (re *RuleEngine) evaluateRule(player *models.MatchRequest, rule models.Rule, elapsedTime time.Duration) bool {
-	// Check if rule should be relaxed
-	if rule.RelaxAfter != nil && elapsedTime.Seconds() >= float64(*rule.RelaxAfter) {
-		return true // Rule is relaxed, always pass
+// evaluateTicketRule evaluates a rule against every player on a ticket
+func (re *RuleEngine) evaluateTicketRule(ticket *models.MatchRequest, rule models.Rule, elapsedTime time.Duration) bool {
+	members := []*models.MatchRequest{ticket}
+	if len(ticket.Members) > 0 {
+		members = ticket.MemberRequests()
 	}
 
-	// Get the field value from player metadata
-	fieldValue, exists := player.Metadata[rule.Field]
-	if !exists {
-		return !rule.Strict // If field doesn't exist and rule is not strict, pass
+	for _, member := range members {
+		if passed, _ := re.evaluateNode(ticket, member, rule, elapsedTime); !passed {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateNode evaluates a rule or rule group for one player on a ticket, and reports whether the rule
+// applied. Relaxed rules, and non-strict rules on a missing field, don't apply: they pass on their own
+// and groups ignore them, so a not group doesn't fail on them and an any group needs another rule to pass.
+func (re *RuleEngine) evaluateNode(ticket, player *models.MatchRequest, rule models.Rule, elapsedTime time.Duration) (passed, applied bool) {
+	// Check if rule should be relaxed
+	if re.isRelaxed(rule, elapsedTime) {
+		return true, false // Rule is relaxed, always pass
 	}
 
-	// Evaluate based on rule type
 	switch {
-	case rule.Min != nil:
-		return re.evaluateMin(fieldValue, *rule.Min)
-	case rule.Max != nil:
-		return re.evaluateMax(fieldValue, *rule.Max)
-	case rule.Contains != nil:
-		return re.evaluateContains(fieldValue, *rule.Contains)
-	case rule.Equals != nil:
-		return re.evaluateEquals(fieldValue, *rule.Equals)
-	default:
-		return true // No specific evaluation criteria, pass
+	case rule.Not != nil:
+		childPassed, childApplied := re.evaluateNode(ticket, player, *rule.Not, elapsedTime)
+		return !childPassed || !childApplied, childApplied
+	case rule.Any != nil:
+		for _, child := range re.byPriority(rule.Any) {
+			childPassed, childApplied := re.evaluateNode(ticket, player, child, elapsedTime)
+			if childApplied && childPassed {
+				return true, true
+			}
+			applied = applied || childApplied
+		}
+		return !applied, applied
+	case rule.All != nil:
+		for _, child := range re.byPriority(rule.All) {
+			childPassed, childApplied := re.evaluateNode(ticket, player, child, elapsedTime)
+			if !childPassed {
+				return false, true
+			}
+			applied = applied || childApplied
+		}
+		return true, applied
 	}
-}
 
-// evaluateMin checks if a value is greater than or equal to min
-func (re *RuleEngine) evaluateMin(value interface{}, min int) bool {
-	switch v := value.(type) {
-	case int:
-		return v >= min
-	case float64:
-		return int(v) >= min
-	case string:
-		// Try to parse as number
-		if num, ok := re.parseNumber(v); ok {
-			return num >= min
+	rule = re.applyRelaxation(rule, elapsedTime)
+
+	// Get the field value from player metadata, or the party's aggregate
+	var fieldValue interface{}
+	var exists bool
+	if rule.PartyAggregate != "" && len(ticket.Members) > 0 {
+		aggregate, ok := re.aggregate(ticket.MemberRequests(), rule.Field, rule.PartyAggregate)
+		fieldValue, exists = aggregate, ok
+	} else {
+		fieldValue, exists = re.FieldValue(player.Metadata, rule.Field)
+	}
+
+	if !exists {
+		if rule.Exists != nil {
+			return !*rule.Exists, true // exists decides regardless of strict
 		}
+		return !rule.Strict, rule.Strict // If field doesn't exist and rule is not strict, pass
+	}
+	return re.evaluateValue(fieldValue, rule), true
+}
+
+// evaluateValue evaluates a field rule's criteria against a value that was found
+func (re *RuleEngine) evaluateValue(fieldValue interface{}, rule models.Rule) bool {
+	if rule.Exists != nil && !*rule.Exists {
 		return false
-	default:
+	}
+
+	// Every criterion set on the rule must hold
+	if rule.Min != nil && !re.evaluateMin(fieldValue, *rule.Min) {
+		return false
+	}
+	if rule.Max != nil && !re.evaluateMax(fieldValue, *rule.Max) {
+		return false
+	}
+	if rule.Contains != nil && !re.evaluateContains(fieldValue, *rule.Contains) {
+		return false
+	}
+	if rule.Equals != nil && !re.evaluateEquals(fieldValue, *rule.Equals) {
 		return false
 	}
+	if rule.NotEquals != nil && re.evaluateEquals(fieldValue, *rule.NotEquals) {
+		return false
+	}
+	if len(rule.In) > 0 && !re.evaluateIn(fieldValue, rule.In) {
+		return false
+	}
+	if len(rule.NotIn) > 0 && re.evaluateIn(fieldValue, rule.NotIn) {
+		return false
+	}
+	if rule.Regex != nil && !re.evaluateRegex(fieldValue, *rule.Regex) {
+		return false
+	}
+
+	return true
 }
 
-// evaluateMax checks if a value is less than or equal to max
-func (re *RuleEngine) evaluateMax(value interface{}, max int) bool {
-	switch v := value.(type) {
-	case int:
-		return v <= max
-	case float64:
-		return int(v) <= max
-	case string:
-		// Try to parse as number
-		if num, ok := re.parseNumber(v); ok {
-			return num <= max
+// isRelaxed reports whether a rule no longer applies after elapsedTime
+func (re *RuleEngine) isRelaxed(rule models.Rule, elapsedTime time.Duration) bool {
+	return rule.RelaxAfter != nil && elapsedTime.Seconds() >= float64(*rule.RelaxAfter)
+}
+
+// applyRelaxation returns the rule with the bounds of every relaxation step reached by elapsedTime applied
+func (re *RuleEngine) applyRelaxation(rule models.Rule, elapsedTime time.Duration) models.Rule {
+	for _, step := range rule.Relaxation {
+		if elapsedTime.Seconds() < float64(step.After) {
+			break
+		}
+		if step.Min != nil {
+			rule.Min = step.Min
+		}
+		if step.Max != nil {
+			rule.Max = step.Max
+		}
+		if step.MaxSpread != nil {
+			rule.MaxSpread = step.MaxSpread
 		}
-		return false
-	default:
-		return false
 	}
+	return rule
+}
+
+// MaxPing returns a latency rule's max ping after elapsedTime, with every relaxation step reached applied.
+// It returns 0, no limit, for a nil rule.
+func (re *RuleEngine) MaxPing(rule *models.LatencyRule, elapsedTime time.Duration) int {
+	if rule == nil {
+		return 0
+	}
+	maxPing := rule.MaxPing
+	for _, step := range rule.Relaxation {
+		if elapsedTime.Seconds() < float64(step.After) {
+			break
+		}
+		maxPing = step.MaxPing
+	}
+	return maxPing
+}
+
+// evaluateMin checks if a numeric value is greater than or equal to min
+func (re *RuleEngine) evaluateMin(value interface{}, min float64) bool {
+	num, ok := re.NumericValue(value)
+	return ok && num >= min
+}
+
+// evaluateMax checks if a numeric value is less than or equal to max
+func (re *RuleEngine) evaluateMax(value interface{}, max float64) bool {
+	num, ok := re.NumericValue(value)
+	return ok && num <= max
 }
 
 // evaluateContains checks if a value contains the specified string
@@ -132,11 +265,99 @@ func (re *RuleEngine) evaluateEquals(value interface{}, equals string) bool {
 	return fmt.Sprintf("%v", value) == equals
 }
 
-// parseNumber attempts to parse a string as a number
-func (re *RuleEngine) parseNumber(s string) (int, bool) {
-	var num int
-	_, err := fmt.Sscanf(s, "%d", &num)
-	return num, err == nil
+// evaluateIn checks if a value, or any element of an array value, is one of the listed values
+func (re *RuleEngine) evaluateIn(value interface{}, values []string) bool {
+	for _, item := range re.elements(value) {
+		text := fmt.Sprintf("%v", item)
+		for _, v := range values {
+			if text == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evaluateRegex checks if a value, or any element of an array value, matches the whole pattern. A pattern
+// the engine hasn't compiled, through ValidateGameConfig or CompileRules, matches nothing.
+func (re *RuleEngine) evaluateRegex(value interface{}, pattern string) bool {
+	re.mu.RLock()
+	compiled, ok := re.patterns[pattern]
+	re.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	for _, item := range re.elements(value) {
+		if compiled.MatchString(fmt.Sprintf("%v", item)) {
+			return true
+		}
+	}
+	return false
+}
+
+// elements returns the items of an array value, or the value itself otherwise
+func (re *RuleEngine) elements(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	default:
+		return []interface{}{v}
+	}
+}
+
+// CompileRules compiles the regexes of rules, and of the rules in their groups, that the engine doesn't
+// hold yet, so evaluating the rules never compiles. Configs validated with ValidateGameConfig are compiled
+// already; call it for rules loaded from elsewhere.
+func (re *RuleEngine) CompileRules(rules []models.Rule) error {
+	for i, rule := range rules {
+		if rule.Regex != nil {
+			if _, err := re.compilePattern(*rule.Regex); err != nil {
+				return fmt.Errorf("rule %d: invalid regex: %w", i, err)
+			}
+		}
+		children := append(append([]models.Rule(nil), rule.All...), rule.Any...)
+		if rule.Not != nil {
+			children = append(children, *rule.Not)
+		}
+		if err := re.CompileRules(children); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// compilePattern returns a rule regex compiled to match whole values, compiling and keeping it the first
+// time it is seen
+func (re *RuleEngine) compilePattern(pattern string) (*regexp.Regexp, error) {
+	re.mu.RLock()
+	compiled, ok := re.patterns[pattern]
+	re.mu.RUnlock()
+	if ok {
+		return compiled, nil
+	}
+
+	compiled, err := re.anchorPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re.mu.Lock()
+	if re.patterns == nil {
+		re.patterns = make(map[string]*regexp.Regexp)
+	}
+	re.patterns[pattern] = compiled
+	re.mu.Unlock()
+	return compiled, nil
+}
+
+// anchorPattern compiles a rule regex so it must match the whole value
+func (re *RuleEngine) anchorPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
 }
 
 // FindCompatiblePlayers finds players that are compatible based on rules
@@ -152,6 +373,222 @@ func (re *RuleEngine) FindCompatiblePlayers(players []*models.MatchRequest, rule
 	return compatible
 }
 
+// EvaluateGroup evaluates the rules that compare players with each other, such as max_spread,
+// across every player on a set of tickets. Players without a numeric value for a rule's field are ignored.
+func (re *RuleEngine) EvaluateGroup(players []*models.MatchRequest, rules []models.Rule, elapsedTime time.Duration) (bool, []string) {
+	var violations []string
+	members := re.expandMembers(players)
+
+	for _, rule := range re.SpreadRules(rules, elapsedTime) {
+		if spread, ok := re.spread(members, rule.Field); ok && spread > *rule.MaxSpread {
+			violation := fmt.Sprintf("Rule '%s' failed: spread %g exceeds %g", rule.Field, spread, *rule.MaxSpread)
+			violations = append(violations, violation)
+		}
+	}
+
+	return len(violations) == 0, violations
+}
+
+// Distance returns how far apart two players are on the fields of the max_spread rules.
+// Each field's difference is divided by its max_spread so fields on different scales weigh the same.
+// A party is placed at its members' average.
+func (re *RuleEngine) Distance(a, b *models.MatchRequest, rules []models.Rule, elapsedTime time.Duration) float64 {
+	var distance float64
+
+	for _, rule := range re.SpreadRules(rules, elapsedTime) {
+		av, aok := re.Position(a, rule.Field)
+		bv, bok := re.Position(b, rule.Field)
+		if !aok || !bok {
+			continue
+		}
+
+		diff := math.Abs(av - bv)
+		if *rule.MaxSpread > 0 {
+			diff /= *rule.MaxSpread
+		}
+		distance += diff
+	}
+
+	return distance
+}
+
+// SpreadRules returns the max_spread rules that still apply after elapsedTime with their relaxation applied,
+// including those nested in all groups
+func (re *RuleEngine) SpreadRules(rules []models.Rule, elapsedTime time.Duration) []models.Rule {
+	var spreadRules []models.Rule
+	for _, rule := range rules {
+		if re.isRelaxed(rule, elapsedTime) {
+			continue
+		}
+		if rule.All != nil {
+			spreadRules = append(spreadRules, re.SpreadRules(rule.All, elapsedTime)...)
+		} else if rule.MaxSpread != nil {
+			spreadRules = append(spreadRules, re.applyRelaxation(rule, elapsedTime))
+		}
+	}
+	return spreadRules
+}
+
+// Position returns where Distance places a ticket on field, its members' average, reporting false if
+// no member has a numeric value for the field
+func (re *RuleEngine) Position(ticket *models.MatchRequest, field string) (float64, bool) {
+	return re.aggregate(ticket.MemberRequests(), field, models.AggregateAvg)
+}
+
+// EvaluateTeams evaluates team rules against the teams of a candidate match
+func (re *RuleEngine) EvaluateTeams(teams [][]*models.MatchRequest, rules []models.TeamRule, elapsedTime time.Duration) (bool, []string) {
+	var violations []string
+
+	for _, rule := range rules {
+		if rule.RelaxAfter != nil && elapsedTime.Seconds() >= float64(*rule.RelaxAfter) {
+			continue
+		}
+		if excess := re.teamRuleExcess(teams, rule); excess > 0 {
+			violation := fmt.Sprintf("Team rule '%s(%s)' failed: out of tolerance by %g", rule.Aggregate, rule.Field, excess)
+			violations = append(violations, violation)
+		}
+	}
+
+	return len(violations) == 0, violations
+}
+
+// TeamViolation returns how far a candidate match's teams are outside the team rules' tolerances.
+// It is 0 when every team rule holds, and smaller values are closer to passing.
+func (re *RuleEngine) TeamViolation(teams [][]*models.MatchRequest, rules []models.TeamRule, elapsedTime time.Duration) float64 {
+	var violation float64
+
+	for _, rule := range rules {
+		if rule.RelaxAfter != nil && elapsedTime.Seconds() >= float64(*rule.RelaxAfter) {
+			continue
+		}
+		violation += re.teamRuleExcess(teams, rule)
+	}
+
+	return violation
+}
+
+// teamRuleExcess returns how far the teams' aggregates fall outside a team rule's bounds
+func (re *RuleEngine) teamRuleExcess(teams [][]*models.MatchRequest, rule models.TeamRule) float64 {
+	var excess, lowest, highest float64
+	found := false
+
+	for _, team := range teams {
+		value, ok := re.aggregate(re.expandMembers(team), rule.Field, rule.Aggregate)
+		if !ok {
+			continue
+		}
+		if rule.Min != nil && value < *rule.Min {
+			excess += *rule.Min - value
+		}
+		if rule.Max != nil && value > *rule.Max {
+			excess += value - *rule.Max
+		}
+		if !found || value < lowest {
+			lowest = value
+		}
+		if !found || value > highest {
+			highest = value
+		}
+		found = true
+	}
+
+	if rule.MaxDiff != nil && found && highest-lowest > *rule.MaxDiff {
+		excess += highest - lowest - *rule.MaxDiff
+	}
+	return excess
+}
+
+// aggregate computes an aggregate of a numeric field over a team, ignoring players without the field
+func (re *RuleEngine) aggregate(players []*models.MatchRequest, field, aggregate string) (float64, bool) {
+	var result float64
+	count := 0
+
+	for _, player := range players {
+		value, ok := re.numericField(player, field)
+		if !ok {
+			continue
+		}
+		switch {
+		case count == 0:
+			result = value
+		case aggregate == models.AggregateMin:
+			result = math.Min(result, value)
+		case aggregate == models.AggregateMax:
+			result = math.Max(result, value)
+		default:
+			result += value
+		}
+		count++
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+	if aggregate == models.AggregateAvg {
+		result /= float64(count)
+	}
+	return result, true
+}
+
+// expandMembers returns one request per player across a set of tickets
+func (re *RuleEngine) expandMembers(tickets []*models.MatchRequest) []*models.MatchRequest {
+	var members []*models.MatchRequest
+	for _, ticket := range tickets {
+		members = append(members, ticket.MemberRequests()...)
+	}
+	return members
+}
+
+// spread returns the difference between the highest and lowest value of a field across players
+func (re *RuleEngine) spread(players []*models.MatchRequest, field string) (float64, bool) {
+	var lowest, highest float64
+	found := false
+
+	for _, player := range players {
+		value, ok := re.numericField(player, field)
+		if !ok {
+			continue
+		}
+		if !found || value < lowest {
+			lowest = value
+		}
+		if !found || value > highest {
+			highest = value
+		}
+		found = true
+	}
+
+	return highest - lowest, found
+}
+
+// numericField resolves a field path against a player's metadata and converts it to a float64
+func (re *RuleEngine) numericField(player *models.MatchRequest, field string) (float64, bool) {
+	value, ok := re.FieldValue(player.Metadata, field)
+	if !ok {
+		return 0, false
+	}
+	return re.NumericValue(value)
+}
+
+// NumericValue converts a metadata value to a float64, reporting false if it isn't numeric
+func (re *RuleEngine) NumericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		num, err := strconv.ParseFloat(v, 64)
+		return num, err == nil
+	default:
+		return 0, false
+	}
+}
+
 // ValidateGameConfig validates a game configuration
 func (re *RuleEngine) ValidateGameConfig(config *models.GameConfig) error {
 	if config.GameID == "" {
@@ -162,23 +599,290 @@ func (re *RuleEngine) ValidateGameConfig(config *models.GameConfig) error {
 		return fmt.Errorf("at least one team must be defined")
 	}
 
+	if config.TicketTTL < 0 {
+		return fmt.Errorf("ticket_ttl must not be negative")
+	}
+
+	if config.FillDeadline < 0 {
+		return fmt.Errorf("fill_deadline must not be negative")
+	}
+
+	if config.AcceptWindow < 0 {
+		return fmt.Errorf("accept_window must not be negative")
+	}
+
+	if config.BalanceBy != "" {
+		if err := re.ValidateFieldPath(config.BalanceBy); err != nil {
+			return fmt.Errorf("balance_by: invalid field path '%s': %w", config.BalanceBy, err)
+		}
+	}
+
+	if config.Latency != nil {
+		if err := re.validateLatency(config.Latency); err != nil {
+			return fmt.Errorf("latency: %w", err)
+		}
+	}
+
 	for i, team := range config.Teams {
 		if team.Name == "" {
 			return fmt.Errorf("team %d: name is required", i)
 		}
-		if team.Size <= 0 {
-			return fmt.Errorf("team %d: size must be greater than 0", i)
+		if err := re.validateTeamSize(team); err != nil {
+			return fmt.Errorf("team %d: %w", i, err)
+		}
+		if err := re.validateTeamRoles(team); err != nil {
+			return fmt.Errorf("team %d: %w", i, err)
 		}
 	}
 
 	for i, rule := range config.Rules {
-		if rule.Field == "" {
-			return fmt.Errorf("rule %d: field is required", i)
+		if err := re.validateRule(rule, true); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+
+	for i, rule := range config.TeamRules {
+		if err := re.validateTeamRule(rule); err != nil {
+			return fmt.Errorf("team rule %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validateRule checks a rule, or a group and every rule nested in it. max_spread compares the whole
+// match, so it is only allowed where spreadAllowed: at the top level and in all groups.
+func (re *RuleEngine) validateRule(rule models.Rule, spreadAllowed bool) error {
+	if rule.IsGroup() {
+		return re.validateGroup(rule, spreadAllowed)
+	}
+
+	if rule.Field == "" {
+		return fmt.Errorf("field is required")
+	}
+	if err := re.ValidateFieldPath(rule.Field); err != nil {
+		return fmt.Errorf("invalid field path '%s': %w", rule.Field, err)
+	}
+
+	// Check that at least one evaluation criteria is set
+	if !re.hasValueCriteria(rule) && rule.Exists == nil {
+		return fmt.Errorf("at least one evaluation criteria (min, max, contains, equals, not_equals, in, not_in, regex, exists, max_spread) must be set")
+	}
+
+	if err := re.validateOperators(rule); err != nil {
+		return err
+	}
+
+	if rule.MaxSpread != nil && *rule.MaxSpread < 0 {
+		return fmt.Errorf("max_spread must not be negative")
+	}
+	if rule.MaxSpread != nil && !spreadAllowed {
+		return fmt.Errorf("max_spread can only be used at the top level or in all groups")
+	}
+
+	if rule.PartyAggregate != "" && !re.isAggregate(rule.PartyAggregate) {
+		return fmt.Errorf("party_aggregate must be one of avg, sum, min, max")
+	}
+
+	if err := re.validateRelaxation(rule); err != nil {
+		return err
+	}
+
+	if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+		return fmt.Errorf("min (%g) must not be greater than max (%g)", *rule.Min, *rule.Max)
+	}
+
+	return nil
+}
+
+// validateGroup checks that a group sets exactly one of all, any or not with at least one rule, and no
+// field or criteria of its own
+func (re *RuleEngine) validateGroup(rule models.Rule, spreadAllowed bool) error {
+	groups := 0
+	for _, set := range []bool{rule.All != nil, rule.Any != nil, rule.Not != nil} {
+		if set {
+			groups++
+		}
+	}
+	if groups > 1 {
+		return fmt.Errorf("a group must set only one of all, any or not")
+	}
+	if rule.Field != "" || re.hasValueCriteria(rule) || rule.Exists != nil || rule.PartyAggregate != "" || len(rule.Relaxation) > 0 {
+		return fmt.Errorf("a group must not set a field or criteria of its own")
+	}
+
+	switch {
+	case rule.Not != nil:
+		if err := re.validateRule(*rule.Not, false); err != nil {
+			return fmt.Errorf("not: %w", err)
+		}
+	case rule.Any != nil:
+		if len(rule.Any) == 0 {
+			return fmt.Errorf("any must list at least one rule")
+		}
+		for i, child := range rule.Any {
+			if err := re.validateRule(child, false); err != nil {
+				return fmt.Errorf("any %d: %w", i, err)
+			}
+		}
+	default:
+		if len(rule.All) == 0 {
+			return fmt.Errorf("all must list at least one rule")
+		}
+		for i, child := range rule.All {
+			if err := re.validateRule(child, spreadAllowed); err != nil {
+				return fmt.Errorf("all %d: %w", i, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasValueCriteria reports whether a rule sets any criterion on the field's value
+func (re *RuleEngine) hasValueCriteria(rule models.Rule) bool {
+	return rule.Min != nil || rule.Max != nil || rule.Contains != nil || rule.Equals != nil || rule.NotEquals != nil ||
+		len(rule.In) > 0 || len(rule.NotIn) > 0 || rule.Regex != nil || rule.MaxSpread != nil
+}
+
+// validateOperators checks that a rule's set operators are non-empty, its regex compiles and
+// exists: false isn't combined with criteria on a value that must be absent
+func (re *RuleEngine) validateOperators(rule models.Rule) error {
+	if rule.In != nil && len(rule.In) == 0 {
+		return fmt.Errorf("in must list at least one value")
+	}
+	if rule.NotIn != nil && len(rule.NotIn) == 0 {
+		return fmt.Errorf("not_in must list at least one value")
+	}
+	if rule.Regex != nil {
+		// The compiled pattern is kept for evaluating the rule
+		if _, err := re.compilePattern(*rule.Regex); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+	if rule.Exists != nil && !*rule.Exists && (re.hasValueCriteria(rule) || len(rule.Relaxation) > 0) {
+		return fmt.Errorf("exists: false can't be combined with other criteria")
+	}
+	return nil
+}
+
+// validateTeamSize checks that a team has a positive max size and a min size within it
+func (re *RuleEngine) validateTeamSize(team models.Team) error {
+	if team.Size < 0 || team.MaxSize < 0 || team.MinSize < 0 {
+		return fmt.Errorf("sizes must not be negative")
+	}
+	if team.MaxPlayers() <= 0 {
+		return fmt.Errorf("size must be greater than 0")
+	}
+	if team.Size > 0 && team.MaxSize > 0 && team.Size != team.MaxSize {
+		return fmt.Errorf("size (%d) and max_size (%d) must match when both are set", team.Size, team.MaxSize)
+	}
+	if team.MinSize > team.MaxPlayers() {
+		return fmt.Errorf("min_size (%d) must not be greater than max size (%d)", team.MinSize, team.MaxPlayers())
+	}
+	return nil
+}
+
+// validateTeamRoles checks that a team's role slots are positive and fill the team exactly
+func (re *RuleEngine) validateTeamRoles(team models.Team) error {
+	if len(team.Roles) == 0 {
+		return nil
+	}
+	slots := 0
+	for role, count := range team.Roles {
+		if role == "" {
+			return fmt.Errorf("role name is required")
+		}
+		if count <= 0 {
+			return fmt.Errorf("role %s: count must be greater than 0", role)
+		}
+		slots += count
+	}
+	if slots != team.MaxPlayers() {
+		return fmt.Errorf("role counts (%d) must add up to size (%d)", slots, team.MaxPlayers())
+	}
+	return nil
+}
+
+// validateTeamRule checks that a team rule names a supported aggregate and has consistent bounds
+func (re *RuleEngine) validateTeamRule(rule models.TeamRule) error {
+	if rule.Field == "" {
+		return fmt.Errorf("field is required")
+	}
+	if err := re.ValidateFieldPath(rule.Field); err != nil {
+		return fmt.Errorf("invalid field path '%s': %w", rule.Field, err)
+	}
+
+	if !re.isAggregate(rule.Aggregate) {
+		return fmt.Errorf("aggregate must be one of avg, sum, min, max")
+	}
+
+	if rule.Min == nil && rule.Max == nil && rule.MaxDiff == nil {
+		return fmt.Errorf("at least one of min, max or max_diff must be set")
+	}
+	if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+		return fmt.Errorf("min (%g) must not be greater than max (%g)", *rule.Min, *rule.Max)
+	}
+	if rule.MaxDiff != nil && *rule.MaxDiff < 0 {
+		return fmt.Errorf("max_diff must not be negative")
+	}
+
+	return nil
+}
+
+// isAggregate reports whether name is a supported aggregate
+func (re *RuleEngine) isAggregate(name string) bool {
+	switch name {
+	case models.AggregateAvg, models.AggregateSum, models.AggregateMin, models.AggregateMax:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateLatency checks that a latency rule has a positive max ping that its relaxation steps only raise
+func (re *RuleEngine) validateLatency(rule *models.LatencyRule) error {
+	if rule.MaxPing <= 0 {
+		return fmt.Errorf("max_ping must be positive")
+	}
+	previous := rule.MaxPing
+	for i, step := range rule.Relaxation {
+		if step.After < 0 {
+			return fmt.Errorf("relaxation step %d: after must not be negative", i)
+		}
+		if i > 0 && step.After <= rule.Relaxation[i-1].After {
+			return fmt.Errorf("relaxation step %d: steps must be in increasing order of after", i)
+		}
+		if step.MaxPing < previous {
+			return fmt.Errorf("relaxation step %d: max_ping (%d) must not be lower than the previous limit (%d)", i, step.MaxPing, previous)
+		}
+		previous = step.MaxPing
+	}
+	return nil
+}
+
+// validateRelaxation checks that a rule's relaxation steps are ordered and keep its bounds consistent
+func (re *RuleEngine) validateRelaxation(rule models.Rule) error {
+	for i, step := range rule.Relaxation {
+		if step.After < 0 {
+			return fmt.Errorf("relaxation step %d: after must not be negative", i)
+		}
+		if i > 0 && step.After <= rule.Relaxation[i-1].After {
+			return fmt.Errorf("relaxation step %d: steps must be in increasing order of after", i)
+		}
+		if step.Min == nil && step.Max == nil && step.MaxSpread == nil {
+			return fmt.Errorf("relaxation step %d: at least one of min, max or max_spread must be set", i)
+		}
+		if step.MaxSpread != nil && rule.MaxSpread == nil {
+			return fmt.Errorf("relaxation step %d: max_spread can only relax a max_spread rule", i)
+		}
+		if step.MaxSpread != nil && *step.MaxSpread < 0 {
+			return fmt.Errorf("relaxation step %d: max_spread must not be negative", i)
 		}
 
-		// Check that at least one evaluation criteria is set
-		if rule.Min == nil && rule.Max == nil && rule.Contains == nil && rule.Equals == nil {
-			return fmt.Errorf("rule %d: at least one evaluation criteria (min, max, contains, equals) must be set", i)
+		relaxed := re.applyRelaxation(rule, time.Duration(step.After)*time.Second)
+		if relaxed.Min != nil && relaxed.Max != nil && *relaxed.Min > *relaxed.Max {
+			return fmt.Errorf("relaxation step %d: min (%g) must not be greater than max (%g)", i, *relaxed.Min, *relaxed.Max)
 		}
 	}
 
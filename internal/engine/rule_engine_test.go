@@ -26,7 +26,7 @@ func TestRuleEngine_EvaluatePlayer(t *testing.T) {
 			rules: []models.Rule{
 				{
 					Field:  "level",
-					Min:    &[]int{20}[0],
+					Min:    &[]float64{20}[0],
 					Strict: true,
 				},
 			},
@@ -42,7 +42,7 @@ func TestRuleEngine_EvaluatePlayer(t *testing.T) {
 			rules: []models.Rule{
 				{
 					Field:  "level",
-					Min:    &[]int{20}[0],
+					Min:    &[]float64{20}[0],
 					Strict: true,
 				},
 			},
@@ -75,25 +75,683 @@ func TestRuleEngine_EvaluatePlayer(t *testing.T) {
 			rules: []models.Rule{
 				{
 					Field:      "level",
-					Min:        &[]int{20}[0],
+					Min:        &[]float64{20}[0],
 					RelaxAfter: &[]int{10}[0],
 					Strict:     false,
 				},
 			},
 			expected: true, // Should pass due to relaxation
 		},
+		{
+			name: "Level within range",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 30,
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "level",
+					Min:    &[]float64{10}[0],
+					Max:    &[]float64{50}[0],
+					Strict: true,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Level above range max",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 60,
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "level",
+					Min:    &[]float64{10}[0],
+					Max:    &[]float64{50}[0],
+					Strict: true,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Level below range min",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 5,
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "level",
+					Min:    &[]float64{10}[0],
+					Max:    &[]float64{50}[0],
+					Strict: true,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Equals checked alongside contains",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"mode": "ranked",
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:    "mode",
+					Contains: &[]string{"ranked"}[0],
+					Equals:   &[]string{"casual"}[0],
+					Strict:   true,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Relaxation step widens range",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"skill_rating": 850,
+				},
+				CreatedAt: time.Now().Add(-20 * time.Second),
+			},
+			rules: []models.Rule{
+				{
+					Field:  "skill_rating",
+					Min:    &[]float64{1000}[0],
+					Max:    &[]float64{2000}[0],
+					Strict: true,
+					Relaxation: []models.RelaxationStep{
+						{After: 15, Min: &[]float64{900}[0], Max: &[]float64{2100}[0]},
+						{After: 30, Min: &[]float64{800}[0], Max: &[]float64{2200}[0]},
+					},
+				},
+			},
+			expected: false, // Only the first step applies after 20 seconds
+		},
+		{
+			name: "Later relaxation step reached",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"skill_rating": 850,
+				},
+				CreatedAt: time.Now().Add(-40 * time.Second),
+			},
+			rules: []models.Rule{
+				{
+					Field:  "skill_rating",
+					Min:    &[]float64{1000}[0],
+					Max:    &[]float64{2000}[0],
+					Strict: true,
+					Relaxation: []models.RelaxationStep{
+						{After: 15, Min: &[]float64{900}[0], Max: &[]float64{2100}[0]},
+						{After: 30, Min: &[]float64{800}[0]},
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Every party member checked",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 25,
+				},
+				Members: []models.PartyMember{
+					{PlayerID: "friend", Metadata: map[string]interface{}{"level": 15}},
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "level",
+					Min:    &[]float64{20}[0],
+					Strict: true,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Party aggregate checked",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 25,
+				},
+				Members: []models.PartyMember{
+					{PlayerID: "friend", Metadata: map[string]interface{}{"level": 15}},
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:          "level",
+					Min:            &[]float64{20}[0],
+					Strict:         true,
+					PartyAggregate: models.AggregateAvg,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Nested field checked",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"stats": map[string]interface{}{"mmr": map[string]interface{}{"ranked": 1800}},
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "stats.mmr.ranked",
+					Min:    &[]float64{2000}[0],
+					Strict: true,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Missing nested field fails strict rule",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"stats": map[string]interface{}{"casual": 1800},
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "stats.mmr.ranked",
+					Min:    &[]float64{1000}[0],
+					Strict: true,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "Array element checked",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"loadout": []interface{}{"sniper", "pistol"},
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:  "loadout[0]",
+					Equals: &[]string{"sniper"}[0],
+					Strict: true,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Party aggregate of nested field checked",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"stats": map[string]interface{}{"level": 25},
+				},
+				Members: []models.PartyMember{
+					{PlayerID: "friend", Metadata: map[string]interface{}{"stats": map[string]interface{}{"level": 15}}},
+				},
+			},
+			rules: []models.Rule{
+				{
+					Field:          "stats.level",
+					Min:            &[]float64{20}[0],
+					Strict:         true,
+					PartyAggregate: models.AggregateAvg,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "Fractional value below min",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"skill_rating": 999.9,
+				},
+			},
+			rules: []models.Rule{
+				{Field: "skill_rating", Min: &[]float64{1000}[0], Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Fractional value above max",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"skill_rating": 2000.1,
+				},
+			},
+			rules: []models.Rule{
+				{Field: "skill_rating", Max: &[]float64{2000}[0], Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Fractional bounds",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"kd_ratio": "1.25",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "kd_ratio", Min: &[]float64{1.2}[0], Max: &[]float64{1.3}[0], Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "Not equals rejects value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"region": "us-west",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "region", NotEquals: &[]string{"us-west"}[0], Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Not equals passes other value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"region": "eu-west",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "region", NotEquals: &[]string{"us-west"}[0], Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "In matches listed value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"mode": "ranked",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "mode", In: []string{"ranked", "tournament"}, Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "In rejects unlisted value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"mode": "casual",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "mode", In: []string{"ranked", "tournament"}, Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "In matches numeric value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"tier": 3.0,
+				},
+			},
+			rules: []models.Rule{
+				{Field: "tier", In: []string{"2", "3"}, Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "In matches any array element",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"maps": []interface{}{"dust", "inferno"},
+				},
+			},
+			rules: []models.Rule{
+				{Field: "maps", In: []string{"inferno"}, Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "Not in rejects listed value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"platform": "pc",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "platform", NotIn: []string{"pc", "mac"}, Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Not in rejects any listed array element",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"flags": []string{"new", "banned"},
+				},
+			},
+			rules: []models.Rule{
+				{Field: "flags", NotIn: []string{"banned"}, Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Not in passes unlisted value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"platform": "console",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "platform", NotIn: []string{"pc", "mac"}, Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "Regex matches whole value",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"build": "v2.14",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "build", Regex: &[]string{`v2\.\d+`}[0], Strict: true},
+			},
+			expected: true,
+		},
+		{
+			name: "Regex is anchored",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"build": "v2.14-beta",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "build", Regex: &[]string{`v2\.\d+`}[0], Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Regex alternatives are anchored",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"build": "xbeta",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "build", Regex: &[]string{"alpha|beta"}[0], Strict: true},
+			},
+			expected: false,
+		},
+		{
+			name: "Exists requires field even when not strict",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 25,
+				},
+			},
+			rules: []models.Rule{
+				{Field: "premium", Exists: &[]bool{true}[0]},
+			},
+			expected: false,
+		},
+		{
+			name: "Exists passes present field",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"premium": false,
+				},
+			},
+			rules: []models.Rule{
+				{Field: "premium", Exists: &[]bool{true}[0]},
+			},
+			expected: true,
+		},
+		{
+			name: "Exists false rejects present field",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"banned_until": "2026-12-01",
+				},
+			},
+			rules: []models.Rule{
+				{Field: "banned_until", Exists: &[]bool{false}[0]},
+			},
+			expected: false,
+		},
+		{
+			name: "Exists false passes missing field",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{
+					"level": 25,
+				},
+			},
+			rules: []models.Rule{
+				{Field: "banned_until", Exists: &[]bool{false}[0], Strict: true},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := engine.CompileRules(tt.rules); err != nil {
+				t.Fatalf("CompileRules() error = %v", err)
+			}
+			result, _ := engine.EvaluatePlayer(tt.player, tt.rules, time.Since(tt.player.CreatedAt))
+			if result != tt.expected {
+				t.Errorf("EvaluatePlayer() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRuleEngine_EvaluatePlayer_RuleGroups(t *testing.T) {
+	engine := NewRuleEngine()
+
+	usWest := models.Rule{Field: "region", Equals: &[]string{"us-west"}[0]}
+	lowPing := models.Rule{Field: "latency_us_west", Max: &[]float64{59}[0], Strict: true}
+	banned := models.Rule{Field: "flags", Contains: &[]string{"banned"}[0], Strict: true}
+	player := func(metadata map[string]interface{}) *models.MatchRequest {
+		return &models.MatchRequest{Metadata: metadata}
+	}
+
+	tests := []struct {
+		name     string
+		player   *models.MatchRequest
+		rules    []models.Rule
+		elapsed  time.Duration
+		expected bool
+	}{
+		{
+			name:     "Any passes on first rule",
+			player:   player(map[string]interface{}{"region": "us-west", "latency_us_west": 120}),
+			rules:    []models.Rule{{Any: []models.Rule{usWest, lowPing}}},
+			expected: true,
+		},
+		{
+			name:     "Any passes on second rule",
+			player:   player(map[string]interface{}{"region": "eu-west", "latency_us_west": 40}),
+			rules:    []models.Rule{{Any: []models.Rule{usWest, lowPing}}},
+			expected: true,
+		},
+		{
+			name:     "Any fails when no rule passes",
+			player:   player(map[string]interface{}{"region": "eu-west", "latency_us_west": 80}),
+			rules:    []models.Rule{{Any: []models.Rule{usWest, lowPing}}},
+			expected: false,
+		},
+		{
+			name:     "Any ignores non-strict rule on missing field",
+			player:   player(map[string]interface{}{"latency_us_west": 80}),
+			rules:    []models.Rule{{Any: []models.Rule{usWest, lowPing}}},
+			expected: false,
+		},
+		{
+			name:     "Any of only non-applying rules passes",
+			player:   player(map[string]interface{}{}),
+			rules:    []models.Rule{{Any: []models.Rule{usWest}}},
+			expected: true,
+		},
+		{
+			name:     "Not fails when its rule passes",
+			player:   player(map[string]interface{}{"flags": []string{"new", "banned"}}),
+			rules:    []models.Rule{{Not: &banned}},
+			expected: false,
+		},
+		{
+			name:     "Not passes when its rule fails",
+			player:   player(map[string]interface{}{"flags": []string{"new"}}),
+			rules:    []models.Rule{{Not: &banned}},
+			expected: true,
+		},
+		{
+			name:     "Not passes when its rule doesn't apply",
+			player:   player(map[string]interface{}{}),
+			rules:    []models.Rule{{Not: &usWest}},
+			expected: true,
+		},
+		{
+			name:   "Nested groups",
+			player: player(map[string]interface{}{"region": "eu-west", "latency_us_west": 40, "flags": []string{"banned"}}),
+			rules: []models.Rule{{All: []models.Rule{
+				{Any: []models.Rule{usWest, lowPing}},
+				{Not: &banned},
+			}}},
+			expected: false,
+		},
+		{
+			name:     "Flat list is an implicit all",
+			player:   player(map[string]interface{}{"region": "us-west", "latency_us_west": 80}),
+			rules:    []models.Rule{usWest, lowPing},
+			expected: false,
+		},
+		{
+			name:     "Group relaxed after wait",
+			player:   player(map[string]interface{}{"region": "eu-west", "latency_us_west": 80}),
+			rules:    []models.Rule{{Any: []models.Rule{usWest, lowPing}, RelaxAfter: &[]int{30}[0]}},
+			elapsed:  time.Minute,
+			expected: true,
+		},
+		{
+			name:   "Nested rule relaxed after wait",
+			player: player(map[string]interface{}{"region": "eu-west", "latency_us_west": 80}),
+			rules: []models.Rule{{Any: []models.Rule{
+				usWest,
+				{Field: "latency_us_west", Max: &[]float64{59}[0], Strict: true, Relaxation: []models.RelaxationStep{{After: 30, Max: &[]float64{100}[0]}}},
+			}}},
+			elapsed:  time.Minute,
+			expected: true,
+		},
+		{
+			name: "Every party member checked against the group",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{"region": "us-west", "latency_us_west": 120},
+				Members: []models.PartyMember{
+					{PlayerID: "friend", Metadata: map[string]interface{}{"region": "eu-west", "latency_us_west": 90}},
+				},
+			},
+			rules:    []models.Rule{{Any: []models.Rule{usWest, lowPing}}},
+			expected: false,
+		},
+		{
+			name: "Party aggregate inside a group",
+			player: &models.MatchRequest{
+				Metadata: map[string]interface{}{"region": "eu-west", "latency_us_west": 20},
+				Members: []models.PartyMember{
+					{PlayerID: "friend", Metadata: map[string]interface{}{"region": "eu-west", "latency_us_west": 90}},
+				},
+			},
+			rules: []models.Rule{{Any: []models.Rule{
+				usWest,
+				{Field: "latency_us_west", Max: &[]float64{59}[0], Strict: true, PartyAggregate: models.AggregateAvg},
+			}}},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, _ := engine.EvaluatePlayer(tt.player, tt.rules, time.Since(tt.player.CreatedAt))
+			result, violations := engine.EvaluatePlayer(tt.player, tt.rules, tt.elapsed)
 			if result != tt.expected {
-				t.Errorf("EvaluatePlayer() = %v, want %v", result, tt.expected)
+				t.Errorf("EvaluatePlayer() = %v (%v), want %v", result, violations, tt.expected)
 			}
 		})
 	}
 }
 
+func TestRuleEngine_EvaluatePlayer_GroupViolations(t *testing.T) {
+	engine := NewRuleEngine()
+	player := &models.MatchRequest{Metadata: map[string]interface{}{"region": "eu-west", "level": 5, "flags": []string{"banned"}}}
+
+	rules := []models.Rule{
+		{Field: "level", Min: &[]float64{10}[0], Priority: 1},
+		{Not: &models.Rule{Field: "flags", Contains: &[]string{"banned"}[0]}, Priority: 5},
+		{Any: []models.Rule{
+			{Field: "region", Equals: &[]string{"us-west"}[0]},
+			{Field: "region", Equals: &[]string{"us-east"}[0]},
+		}, Priority: 3},
+	}
+
+	_, violations := engine.EvaluatePlayer(player, rules, 0)
+	expected := []string{"Rule 'not(flags)' failed", "Rule 'any(region, region)' failed", "Rule 'level' failed"}
+	if len(violations) != len(expected) {
+		t.Fatalf("EvaluatePlayer() violations = %v, want %v", violations, expected)
+	}
+	for i := range expected {
+		if violations[i] != expected[i] {
+			t.Errorf("EvaluatePlayer() violation %d = %q, want %q", i, violations[i], expected[i])
+		}
+	}
+}
+
+func TestRuleEngine_PatternCache(t *testing.T) {
+	engine := NewRuleEngine()
+	player := &models.MatchRequest{Metadata: map[string]interface{}{"name": "clan-alpha"}}
+	rules := []models.Rule{{Field: "name", Regex: &[]string{"clan-[a-z]+"}[0], Strict: true}}
+	config := &models.GameConfig{
+		GameID: "game",
+		Teams:  []models.Team{{Name: "red", Size: 1}},
+		Rules:  rules,
+	}
+
+	// Evaluating never compiles, so a pattern the engine hasn't seen matches nothing
+	if passed, _ := engine.EvaluatePlayer(player, rules, 0); passed {
+		t.Errorf("EvaluatePlayer() before validation = true, want false")
+	}
+	if len(engine.patterns) != 0 {
+		t.Errorf("patterns compiled by evaluation = %d, want 0", len(engine.patterns))
+	}
+
+	// Validating a config keeps its compiled patterns for evaluation
+	if err := engine.ValidateGameConfig(config); err != nil {
+		t.Fatalf("ValidateGameConfig() error = %v", err)
+	}
+	compiled := engine.patterns["clan-[a-z]+"]
+	if compiled == nil {
+		t.Fatalf("ValidateGameConfig() didn't keep the compiled pattern")
+	}
+	for i := 0; i < 2; i++ {
+		if passed, _ := engine.EvaluatePlayer(player, rules, 0); !passed {
+			t.Errorf("EvaluatePlayer() = false, want true")
+		}
+	}
+	if len(engine.patterns) != 1 || engine.patterns["clan-[a-z]+"] != compiled {
+		t.Errorf("patterns after evaluation = %v, want the validated pattern only", engine.patterns)
+	}
+
+	// Rules loaded without validation, including grouped ones, are compiled once by CompileRules
+	other := NewRuleEngine()
+	grouped := []models.Rule{{Any: []models.Rule{{Not: &rules[0]}, {Field: "name", Regex: &[]string{"guild-.*"}[0]}}}}
+	if err := other.CompileRules(grouped); err != nil {
+		t.Fatalf("CompileRules() error = %v", err)
+	}
+	if len(other.patterns) != 2 {
+		t.Errorf("patterns after CompileRules() = %d, want 2", len(other.patterns))
+	}
+	if err := other.CompileRules([]models.Rule{{Field: "name", Regex: &[]string{"clan-("}[0]}}); err == nil {
+		t.Errorf("CompileRules() accepted an invalid regex")
+	}
+}
+
 func TestRuleEngine_ValidateGameConfig(t *testing.T) {
 	engine := NewRuleEngine()
 
@@ -103,66 +761,529 @@ func TestRuleEngine_ValidateGameConfig(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name: "Valid config",
+			name: "Valid config",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams: []models.Team{
+					{Name: "Solo", Size: 1},
+					{Name: "Duo", Size: 2},
+				},
+				Rules: []models.Rule{
+					{
+						Field:  "level",
+						Min:    &[]float64{20}[0],
+						Strict: true,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Missing game ID",
+			config: &models.GameConfig{
+				Teams: []models.Team{
+					{Name: "Solo", Size: 1},
+				},
+				Rules: []models.Rule{
+					{
+						Field:  "level",
+						Min:    &[]float64{20}[0],
+						Strict: true,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "No teams",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Rules: []models.Rule{
+					{
+						Field:  "level",
+						Min:    &[]float64{20}[0],
+						Strict: true,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid team size",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams: []models.Team{
+					{Name: "Solo", Size: 0},
+				},
+				Rules: []models.Rule{
+					{
+						Field:  "level",
+						Min:    &[]float64{20}[0],
+						Strict: true,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Min greater than max",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field: "level",
+						Min:   &[]float64{50}[0],
+						Max:   &[]float64{10}[0],
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Min equal to max",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field: "level",
+						Min:   &[]float64{10}[0],
+						Max:   &[]float64{10}[0],
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Spread-only rule",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field:     "skill_rating",
+						MaxSpread: &[]float64{150}[0],
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Negative max spread",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field:     "skill_rating",
+						MaxSpread: &[]float64{-1}[0],
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Relaxation steps out of order",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field: "level",
+						Min:   &[]float64{20}[0],
+						Relaxation: []models.RelaxationStep{
+							{After: 30, Min: &[]float64{10}[0]},
+							{After: 15, Min: &[]float64{15}[0]},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Relaxation step crosses min over max",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field: "level",
+						Min:   &[]float64{20}[0],
+						Max:   &[]float64{50}[0],
+						Relaxation: []models.RelaxationStep{
+							{After: 15, Min: &[]float64{60}[0]},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty relaxation step",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field:      "level",
+						Min:        &[]float64{20}[0],
+						Relaxation: []models.RelaxationStep{{After: 15}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid relaxation steps",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field:     "skill_rating",
+						MaxSpread: &[]float64{100}[0],
+						Relaxation: []models.RelaxationStep{
+							{After: 15, MaxSpread: &[]float64{200}[0]},
+							{After: 30, MaxSpread: &[]float64{400}[0]},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Valid team rule",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "A", Size: 2}, {Name: "B", Size: 2}},
+				TeamRules: []models.TeamRule{{Field: "mmr", Aggregate: models.AggregateAvg, MaxDiff: &[]float64{50}[0]}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Unknown team aggregate",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "A", Size: 2}},
+				TeamRules: []models.TeamRule{{Field: "mmr", Aggregate: "median", Max: &[]float64{50}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Team rule without bounds",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "A", Size: 2}},
+				TeamRules: []models.TeamRule{{Field: "level", Aggregate: models.AggregateSum}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Unknown party aggregate",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "Solo", Size: 1}},
+				Rules: []models.Rule{
+					{
+						Field:          "level",
+						Min:            &[]float64{20}[0],
+						PartyAggregate: "median",
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative ticket TTL",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "Solo", Size: 1}},
+				TicketTTL: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid role composition",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 5, Roles: map[string]int{"tank": 1, "healer": 1, "dps": 3}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Role counts don't fill team",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 5, Roles: map[string]int{"tank": 1, "dps": 3}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Flexible team size",
+			config: &models.GameConfig{
+				GameID:       "test-game",
+				Teams:        []models.Team{{Name: "A", MinSize: 3, MaxSize: 5}, {Name: "B", MinSize: 3, MaxSize: 5}},
+				FillDeadline: 60,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Min size greater than max size",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", MinSize: 6, MaxSize: 5}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Size and max size disagree",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 4, MaxSize: 5}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative fill deadline",
+			config: &models.GameConfig{
+				GameID:       "test-game",
+				Teams:        []models.Team{{Name: "A", Size: 5}},
+				FillDeadline: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Negative accept window",
+			config: &models.GameConfig{
+				GameID:       "test-game",
+				Teams:        []models.Team{{Name: "A", Size: 5}},
+				AcceptWindow: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Zero role count",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2, Roles: map[string]int{"tank": 0, "dps": 2}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid latency rule",
 			config: &models.GameConfig{
 				GameID: "test-game",
-				Teams: []models.Team{
-					{Name: "Solo", Size: 1},
-					{Name: "Duo", Size: 2},
-				},
-				Rules: []models.Rule{
-					{
-						Field:  "level",
-						Min:    &[]int{20}[0],
-						Strict: true,
-					},
+				Teams:  []models.Team{{Name: "A", Size: 5}},
+				Latency: &models.LatencyRule{
+					MaxPing:    60,
+					Relaxation: []models.LatencyStep{{After: 30, MaxPing: 100}, {After: 60, MaxPing: 150}},
 				},
 			},
 			wantErr: false,
 		},
 		{
-			name: "Missing game ID",
+			name: "Latency rule without max ping",
 			config: &models.GameConfig{
-				Teams: []models.Team{
-					{Name: "Solo", Size: 1},
-				},
-				Rules: []models.Rule{
-					{
-						Field:  "level",
-						Min:    &[]int{20}[0],
-						Strict: true,
-					},
-				},
+				GameID:  "test-game",
+				Teams:   []models.Team{{Name: "A", Size: 5}},
+				Latency: &models.LatencyRule{},
 			},
 			wantErr: true,
 		},
 		{
-			name: "No teams",
+			name: "Latency relaxation lowers max ping",
 			config: &models.GameConfig{
 				GameID: "test-game",
-				Rules: []models.Rule{
-					{
-						Field:  "level",
-						Min:    &[]int{20}[0],
-						Strict: true,
-					},
+				Teams:  []models.Team{{Name: "A", Size: 5}},
+				Latency: &models.LatencyRule{
+					MaxPing:    100,
+					Relaxation: []models.LatencyStep{{After: 30, MaxPing: 80}},
 				},
 			},
 			wantErr: true,
 		},
 		{
-			name: "Invalid team size",
+			name: "Nested field paths",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "A", Size: 2}},
+				Rules:     []models.Rule{{Field: "stats.mmr.ranked", MaxSpread: &[]float64{100}[0]}, {Field: "loadout[0]", Equals: &[]string{"sniper"}[0]}},
+				TeamRules: []models.TeamRule{{Field: "stats.mmr.ranked", Aggregate: models.AggregateAvg, MaxDiff: &[]float64{50}[0]}},
+				BalanceBy: "stats.mmr.ranked",
+			},
+			wantErr: false,
+		},
+		{
+			name: "Malformed rule field path",
 			config: &models.GameConfig{
 				GameID: "test-game",
-				Teams: []models.Team{
-					{Name: "Solo", Size: 0},
-				},
-				Rules: []models.Rule{
-					{
-						Field:  "level",
-						Min:    &[]int{20}[0],
-						Strict: true,
-					},
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "stats..mmr", Min: &[]float64{10}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Malformed team rule field path",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "A", Size: 2}},
+				TeamRules: []models.TeamRule{{Field: "loadout[x]", Aggregate: models.AggregateSum, Max: &[]float64{50}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Malformed balance_by field path",
+			config: &models.GameConfig{
+				GameID:    "test-game",
+				Teams:     []models.Team{{Name: "A", Size: 2}},
+				BalanceBy: "stats.",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid operators",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "mode", NotEquals: &[]string{"casual"}[0], In: []string{"ranked"}, NotIn: []string{"banned"}, Regex: &[]string{"rank.*"}[0]}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Exists-only rule",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "premium", Exists: &[]bool{true}[0]}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fractional min greater than max",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "kd_ratio", Min: &[]float64{1.5}[0], Max: &[]float64{1.25}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty in list",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "mode", In: []string{}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty not_in list",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "mode", NotIn: []string{}, Equals: &[]string{"ranked"}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid regex",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "build", Regex: &[]string{"v2.(\\d+"}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Exists false with other criteria",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "level", Exists: &[]bool{false}[0], Min: &[]float64{10}[0]}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Valid nested rule groups",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Any: []models.Rule{{Field: "region", Equals: &[]string{"us-west"}[0]}, {All: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}, {Not: &models.Rule{Field: "flags", Contains: &[]string{"banned"}[0]}}}}}, RelaxAfter: &[]int{60}[0]}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Max spread in all group",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{All: []models.Rule{{Field: "skill", MaxSpread: &[]float64{100}[0]}, {Field: "region", Equals: &[]string{"us-west"}[0]}}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Max spread in any group",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Any: []models.Rule{{Field: "skill", MaxSpread: &[]float64{100}[0]}, {Field: "region", Equals: &[]string{"us-west"}[0]}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Max spread in not group",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Not: &models.Rule{Field: "skill", MaxSpread: &[]float64{100}[0]}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty any group",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Any: []models.Rule{}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Group with two operators",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{All: []models.Rule{{Field: "region", Equals: &[]string{"us-west"}[0]}}, Not: &models.Rule{Field: "level", Min: &[]float64{10}[0]}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Group with a field",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Field: "region", Any: []models.Rule{{Field: "region", Equals: &[]string{"us-west"}[0]}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Invalid nested rule",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 2}},
+				Rules:  []models.Rule{{Any: []models.Rule{{Field: "region", Equals: &[]string{"us-west"}[0]}, {All: []models.Rule{{Field: "level"}}}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Latency relaxation out of order",
+			config: &models.GameConfig{
+				GameID: "test-game",
+				Teams:  []models.Team{{Name: "A", Size: 5}},
+				Latency: &models.LatencyRule{
+					MaxPing:    60,
+					Relaxation: []models.LatencyStep{{After: 60, MaxPing: 100}, {After: 30, MaxPing: 150}},
 				},
 			},
 			wantErr: true,
@@ -206,7 +1327,7 @@ func TestRuleEngine_FindCompatiblePlayers(t *testing.T) {
 	rules := []models.Rule{
 		{
 			Field:  "level",
-			Min:    &[]int{20}[0],
+			Min:    &[]float64{20}[0],
 			Strict: true,
 		},
 	}
@@ -223,4 +1344,199 @@ func TestRuleEngine_FindCompatiblePlayers(t *testing.T) {
 			t.Errorf("Player with level %d should not be compatible", level)
 		}
 	}
-} 
\ No newline at end of file
+} 
+
+func TestRuleEngine_EvaluateGroup(t *testing.T) {
+	engine := NewRuleEngine()
+
+	rules := []models.Rule{
+		{
+			Field:      "skill_rating",
+			MaxSpread:  &[]float64{150}[0],
+			RelaxAfter: &[]int{60}[0],
+			Relaxation: []models.RelaxationStep{{After: 15, MaxSpread: &[]float64{300}[0]}},
+		},
+	}
+	player := func(skill interface{}) *models.MatchRequest {
+		return &models.MatchRequest{Metadata: map[string]interface{}{"skill_rating": skill}}
+	}
+
+	tests := []struct {
+		name     string
+		players  []*models.MatchRequest
+		elapsed  time.Duration
+		expected bool
+	}{
+		{
+			name:     "Within spread",
+			players:  []*models.MatchRequest{player(1000), player(1100.5), player("1150")},
+			expected: true,
+		},
+		{
+			name:     "Exceeds spread",
+			players:  []*models.MatchRequest{player(1000), player(1100), player(1151)},
+			expected: false,
+		},
+		{
+			name:     "Players without the field are ignored",
+			players:  []*models.MatchRequest{player(1000), {Metadata: map[string]interface{}{}}},
+			expected: true,
+		},
+		{
+			name:     "Exceeds relaxed spread",
+			players:  []*models.MatchRequest{player(1000), player(1400)},
+			elapsed:  20 * time.Second,
+			expected: false,
+		},
+		{
+			name:     "Within relaxed spread",
+			players:  []*models.MatchRequest{player(1000), player(1250)},
+			elapsed:  20 * time.Second,
+			expected: true,
+		},
+		{
+			name:     "Relaxed after wait",
+			players:  []*models.MatchRequest{player(1000), player(2000)},
+			elapsed:  time.Minute,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, violations := engine.EvaluateGroup(tt.players, rules, tt.elapsed)
+			if result != tt.expected {
+				t.Errorf("EvaluateGroup() = %v (%v), want %v", result, violations, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRuleEngine_EvaluateGroup_NestedRules(t *testing.T) {
+	engine := NewRuleEngine()
+
+	rules := []models.Rule{
+		{All: []models.Rule{{Field: "skill_rating", MaxSpread: &[]float64{150}[0]}}, RelaxAfter: &[]int{60}[0]},
+	}
+	players := []*models.MatchRequest{
+		{Metadata: map[string]interface{}{"skill_rating": 1000}},
+		{Metadata: map[string]interface{}{"skill_rating": 1200}},
+	}
+
+	if ok, _ := engine.EvaluateGroup(players, rules, 0); ok {
+		t.Errorf("EvaluateGroup() passed a spread of 200 with max_spread 150 in an all group")
+	}
+	if ok, violations := engine.EvaluateGroup(players, rules, time.Minute); !ok {
+		t.Errorf("EvaluateGroup() failed after the group relaxed: %v", violations)
+	}
+	if distance := engine.Distance(players[0], players[1], rules, 0); distance != 200.0/150 {
+		t.Errorf("Distance() = %v, want %v", distance, 200.0/150)
+	}
+}
+
+func TestRuleEngine_Distance(t *testing.T) {
+	engine := NewRuleEngine()
+
+	rules := []models.Rule{
+		{Field: "skill_rating", MaxSpread: &[]float64{100}[0]},
+		{Field: "latency", MaxSpread: &[]float64{50}[0]},
+		{Field: "level", Min: &[]float64{10}[0]},
+	}
+	a := &models.MatchRequest{Metadata: map[string]interface{}{"skill_rating": 1000, "latency": 20, "level": 10}}
+	b := &models.MatchRequest{Metadata: map[string]interface{}{"skill_rating": 1050, "latency": 45, "level": 90}}
+
+	// 50/100 for skill plus 25/50 for latency; the min rule doesn't count
+	if distance := engine.Distance(a, b, rules, 0); distance != 1 {
+		t.Errorf("Distance() = %v, want 1", distance)
+	}
+}
+
+func TestRuleEngine_MaxPing(t *testing.T) {
+	engine := NewRuleEngine()
+	rule := &models.LatencyRule{
+		MaxPing:    60,
+		Relaxation: []models.LatencyStep{{After: 30, MaxPing: 100}, {After: 60, MaxPing: 150}},
+	}
+
+	tests := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 60},
+		{29 * time.Second, 60},
+		{30 * time.Second, 100},
+		{2 * time.Minute, 150},
+	}
+	for _, tt := range tests {
+		if got := engine.MaxPing(rule, tt.elapsed); got != tt.want {
+			t.Errorf("MaxPing(%v) = %d, want %d", tt.elapsed, got, tt.want)
+		}
+	}
+
+	if got := engine.MaxPing(nil, time.Minute); got != 0 {
+		t.Errorf("MaxPing(nil) = %d, want 0", got)
+	}
+}
+
+func TestRuleEngine_EvaluateTeams(t *testing.T) {
+	engine := NewRuleEngine()
+
+	team := func(values ...interface{}) []*models.MatchRequest {
+		var players []*models.MatchRequest
+		for _, v := range values {
+			players = append(players, &models.MatchRequest{Metadata: map[string]interface{}{"mmr": v}})
+		}
+		return players
+	}
+
+	tests := []struct {
+		name     string
+		teams    [][]*models.MatchRequest
+		rule     models.TeamRule
+		expected bool
+	}{
+		{
+			name:     "Average difference within tolerance",
+			teams:    [][]*models.MatchRequest{team(1000, 1100), team(1020, 1120)},
+			rule:     models.TeamRule{Field: "mmr", Aggregate: models.AggregateAvg, MaxDiff: &[]float64{50}[0]},
+			expected: true,
+		},
+		{
+			name:     "Average difference out of tolerance",
+			teams:    [][]*models.MatchRequest{team(1000, 1100), team(1100, 1120)},
+			rule:     models.TeamRule{Field: "mmr", Aggregate: models.AggregateAvg, MaxDiff: &[]float64{50}[0]},
+			expected: false,
+		},
+		{
+			name:     "Sum above max",
+			teams:    [][]*models.MatchRequest{team(100, 101), team(50, 50)},
+			rule:     models.TeamRule{Field: "mmr", Aggregate: models.AggregateSum, Max: &[]float64{200}[0]},
+			expected: false,
+		},
+		{
+			name:     "Min aggregate above min",
+			teams:    [][]*models.MatchRequest{team(900, "1200"), team(950, 1000.5)},
+			rule:     models.TeamRule{Field: "mmr", Aggregate: models.AggregateMin, Min: &[]float64{900}[0]},
+			expected: true,
+		},
+		{
+			name:     "Max aggregate below max",
+			teams:    [][]*models.MatchRequest{team(900, 1200), team(950, 1300)},
+			rule:     models.TeamRule{Field: "mmr", Aggregate: models.AggregateMax, Max: &[]float64{1250}[0]},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, violations := engine.EvaluateTeams(tt.teams, []models.TeamRule{tt.rule}, 0)
+			if result != tt.expected {
+				t.Errorf("EvaluateTeams() = %v (%v), want %v", result, violations, tt.expected)
+			}
+			if violation := engine.TeamViolation(tt.teams, []models.TeamRule{tt.rule}, 0); (violation == 0) != tt.expected {
+				t.Errorf("TeamViolation() = %v, want passing = %v", violation, tt.expected)
+			}
+		})
+	}
+}
+
@@ -2,6 +2,9 @@ package matchmaker
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"sort"
 	"time"
 
@@ -12,12 +15,28 @@ import (
 // Matchmaker handles the core matchmaking logic
 type Matchmaker struct {
 	ruleEngine *engine.RuleEngine
+	logOutput  io.Writer // where the [MM] progress lines go
 }
 
 // NewMatchmaker creates a new matchmaker instance
 func NewMatchmaker() *Matchmaker {
+	return NewMatchmakerWithRuleEngine(engine.NewRuleEngine())
+}
+
+// NewMatchmakerWithRuleEngine creates a matchmaker evaluating rules with ruleEngine, so rules it has
+// validated and compiled are evaluated without compiling them again
+func NewMatchmakerWithRuleEngine(ruleEngine *engine.RuleEngine) *Matchmaker {
 	return &Matchmaker{
-		ruleEngine: engine.NewRuleEngine(),
+		ruleEngine: ruleEngine,
+		logOutput:  os.Stdout,
+	}
+}
+
+// compileRules compiles the regexes of a config the rule engine hasn't validated, e.g. one stored by
+// another replica, before its rules are evaluated
+func (m *Matchmaker) compileRules(config *models.GameConfig) {
+	if err := m.ruleEngine.CompileRules(config.Rules); err != nil {
+		fmt.Fprintf(m.logOutput, "[MM] Game %s has invalid rules: %v\n", config.GameID, err)
 	}
 }
 
@@ -38,262 +57,1001 @@ type MultiTeamMatch struct {
 	Session   *models.GameSession `json:"session,omitempty"`
 }
 
-// ProcessMatchPool processes a pool of players and attempts to form matches
-// Now uses full-team matching by default - only creates matches when all teams can be filled
+// ProcessMatchPool forms matches like ProcessFullTeamMatchPool and flattens each into a single match
+// holding every player, named after the first team for backward compatibility
 func (m *Matchmaker) ProcessMatchPool(players []*models.MatchRequest, config *models.GameConfig) []*models.Match {
 	var matches []*models.Match
-	usedPlayers := make(map[string]bool)
-	teamCount := len(config.Teams)
-	if teamCount == 0 {
+	for _, result := range m.ProcessMatchPoolWithRequests(players, config) {
+		matches = append(matches, result.Match)
+	}
+	return matches
+}
+
+// ProcessMatchPoolWithRequests forms matches like ProcessMatchPool, returning both the matches and the
+// request IDs for each match.
+func (m *Matchmaker) ProcessMatchPoolWithRequests(players []*models.MatchRequest, config *models.GameConfig) []MatchWithRequests {
+	var results []MatchWithRequests
+	for _, match := range m.ProcessFullTeamMatchPool(players, config) {
+		var playerIDs []string
+		for _, team := range config.Teams {
+			playerIDs = append(playerIDs, match.Teams[team.Name]...)
+		}
+		legacy := models.NewMatch(config.GameID, config.Teams[0].Name, playerIDs)
+		legacy.Region = match.Region
+		results = append(results, MatchWithRequests{
+			Match:      legacy,
+			RequestIDs: match.RequestIDs,
+		})
+	}
+	return results
+}
+
+// ProcessFullTeamMatchPool processes a pool of tickets and forms matches only when all teams can be filled.
+// Each match is built around an anchor, the longest-waiting ticket that can still be matched, and
+// filled with the compatible tickets the config's strategy ranks best, by default those closest to the
+// anchor on the max_spread rules. A party ticket is never split: all of its players land on the same
+// team. Teams with a role composition only take players who can fill their role slots. The strategy
+// then splits the tickets across teams, and they are shuffled between teams until the config's team rules hold.
+// Teams are filled to their max size; once the anchor has waited past the config's fill deadline, a
+// match may launch with teams of at least their min size, no team more than one player shorter than another.
+// Each match is hosted in the region with the lowest worst ping among its players, and players are only
+// matched together while some region stays within the config's max ping for all of them.
+func (m *Matchmaker) ProcessFullTeamMatchPool(players []*models.MatchRequest, config *models.GameConfig) []*models.MultiTeamMatch {
+	fmt.Fprintf(m.logOutput, "[MM] Starting ProcessFullTeamMatchPool: %d players, %d teams\n", len(players), len(config.Teams))
+	var matches []*models.MultiTeamMatch
+	if len(config.Teams) == 0 {
+		fmt.Fprintln(m.logOutput, "[MM] No teams in config, aborting.")
 		return matches
 	}
+	m.compileRules(config)
 
-	// Continue forming matches while all teams can be filled
-	for {
-		selected := make(map[string][]*models.MatchRequest) // team name -> players
-		usedInThisRound := make(map[string]bool)
+	// Backfill tickets are filled by ProcessBackfill, not matched with each other
+	var tickets []*models.MatchRequest
+	for _, player := range players {
+		if player.Backfill == nil {
+			tickets = append(tickets, player)
+		}
+	}
+	players = tickets
 
-		// For each team, try to select enough compatible players
-		for _, team := range config.Teams {
-			available := m.getAvailablePlayers(players, usedPlayers)
-			if len(available) < team.Size {
-				goto done // Not enough players for this team
-			}
+	strategy, ok := LookupStrategy(config.Strategy)
+	if !ok {
+		fmt.Fprintf(m.logOutput, "[MM] Unknown strategy %q, using %q\n", config.Strategy, DefaultStrategy)
+		strategy, _ = LookupStrategy(DefaultStrategy)
+	}
 
-			// Find compatible players for this team
-			oldest := m.findOldestPlayer(available)
-			elapsed := time.Since(oldest.CreatedAt)
-			compatible := m.ruleEngine.FindCompatiblePlayers(available, config.Rules, elapsed)
+	// Short-handed layouts are only tried past a fill deadline, so games without one never build them
+	fullTeams := m.teamLayout(config.Teams, nil)
+	matchSize := m.layoutSize(fullTeams)
+	var shortLayouts [][]models.Team
+	if config.FillDeadline > 0 {
+		shortLayouts = m.shortLayouts(config.Teams)
+		if len(shortLayouts) > 0 {
+			matchSize = m.layoutSize(shortLayouts[len(shortLayouts)-1])
+		}
+	}
 
-			// Remove already selected in this round
-			var filtered []*models.MatchRequest
-			for _, p := range compatible {
-				if !usedInThisRound[p.ID] {
-					filtered = append(filtered, p)
+	usedPlayers := make(map[string]bool)
+	if batch, ok := strategy.(BatchStrategy); ok {
+	groups:
+		for _, group := range batch.Batch(players, config) {
+			for _, req := range group {
+				if usedPlayers[req.ID] {
+					continue groups
 				}
 			}
-
-			if len(filtered) < team.Size {
-				goto done // Not enough compatible players for this team
+			teams := m.assignTeams(group, config, strategy, fullTeams, m.waitTime(group))
+			if teams == nil {
+				continue
+			}
+			for _, req := range group {
+				usedPlayers[req.ID] = true
 			}
+			matches = append(matches, m.newMatch(config, teams))
+		}
+	}
+
+	// The pool is sorted once per pass: by wait time for picking anchors, and by position on the spread
+	// field for taking each anchor's candidates from a window around it
+	players = m.sortByWaitTime(players)
+	index := m.newSpreadIndex(players, config.Rules)
+	failedAnchors := make(map[string]bool) // anchors that cannot be matched from the remaining tickets
+	for {
+		available := m.getAvailablePlayers(players, usedPlayers)
+		if count := m.countPlayers(available); count < matchSize {
+			fmt.Fprintf(m.logOutput, "[MM] Not enough players for a match: have %d, need %d\n", count, matchSize)
+			break
+		}
 
-			// Select the best players for this team
-			selectedPlayers := m.selectBestPlayers(filtered, team.Size, oldest.CreatedAt)
-			selected[team.Name] = selectedPlayers
-			for _, p := range selectedPlayers {
-				usedInThisRound[p.ID] = true
+		var teams [][]*models.MatchRequest
+		for _, anchor := range available {
+			if failedAnchors[anchor.ID] {
+				continue
+			}
+			elapsed := time.Since(anchor.CreatedAt)
+			candidates := index.window(anchor, available, usedPlayers, elapsed)
+			if teams = m.formTeams(anchor, candidates, config, strategy, fullTeams, elapsed); teams != nil {
+				break
+			}
+			if m.pastFillDeadline(anchor, config) {
+				for _, layout := range shortLayouts {
+					if teams = m.formTeams(anchor, candidates, config, strategy, layout, elapsed); teams != nil {
+						break
+					}
+				}
+				if teams != nil {
+					break
+				}
 			}
+			failedAnchors[anchor.ID] = true
+		}
+		if teams == nil {
+			fmt.Fprintln(m.logOutput, "[MM] No anchor can fill all teams")
+			break
 		}
 
-		// If we get here, all teams are filled - create a single match with all players
-		allPlayerIDs := make([]string, 0)
-		for _, reqs := range selected {
-			for _, req := range reqs {
+		for _, team := range teams {
+			for _, req := range team {
 				usedPlayers[req.ID] = true
-				allPlayerIDs = append(allPlayerIDs, req.PlayerID)
 			}
 		}
-
-		// Create a single match containing all players from all teams
-		// Use the first team name as the match team name for backward compatibility
-		firstTeamName := config.Teams[0].Name
-		match := models.NewMatch(config.GameID, firstTeamName, allPlayerIDs)
-		matches = append(matches, match)
+		matches = append(matches, m.newMatch(config, teams))
 	}
-done:
+	fmt.Fprintf(m.logOutput, "[MM] Done. Formed %d matches.\n", len(matches))
 	return matches
 }
 
-// ProcessMatchPoolWithRequests processes a pool of players and attempts to form matches,
-// returning both the matches and the request IDs for each match.
-// Now uses full-team matching by default
-func (m *Matchmaker) ProcessMatchPoolWithRequests(players []*models.MatchRequest, config *models.GameConfig) []MatchWithRequests {
-	var results []MatchWithRequests
-	usedPlayers := make(map[string]bool)
-	teamCount := len(config.Teams)
-	if teamCount == 0 {
-		return results
+// newMatch builds a match from tickets split into the config's teams, in config order
+func (m *Matchmaker) newMatch(config *models.GameConfig, teams [][]*models.MatchRequest) *models.MultiTeamMatch {
+	teamMap := make(map[string][]string)
+	playerMetadata := make(map[string]map[string]interface{})
+	var requestIDs []string
+	for i, team := range config.Teams {
+		for _, req := range teams[i] {
+			requestIDs = append(requestIDs, req.ID)
+			teamMap[team.Name] = append(teamMap[team.Name], req.PlayerIDs()...)
+			for _, member := range req.MemberRequests() {
+				playerMetadata[member.PlayerID] = member.Metadata
+			}
+		}
+	}
+	fmt.Fprintf(m.logOutput, "[MM] Forming match: %v\n", teamMap)
+	match := &models.MultiTeamMatch{
+		ID:             models.NewMatch(config.GameID, "multi", nil).ID, // reuse uuid
+		GameID:         config.GameID,
+		Teams:          teamMap,
+		CreatedAt:      time.Now(),
+		RequestIDs:     requestIDs,
+		PlayerMetadata: playerMetadata,
+	}
+	sort.Strings(match.RequestIDs)
+	var tickets []*models.MatchRequest
+	for _, team := range teams {
+		tickets = append(tickets, team...)
+	}
+	match.Region, _ = m.selectRegion(tickets, config, m.waitTime(tickets))
+	if config.BalanceBy != "" {
+		match.TeamTotals = m.teamTotals(teams, config.Teams, config.BalanceBy)
+	}
+	if m.hasRoles(config.Teams) {
+		match.Roles = make(map[string]string)
+		for i, team := range config.Teams {
+			for playerID, role := range m.assignRoles(teams[i], team) {
+				match.Roles[playerID] = role
+			}
+		}
 	}
+	return match
+}
 
-	// Continue forming matches while all teams can be filled
-	for {
-		// Log the number of requests being processed
-		fmt.Printf("[Matchmaker] Processing %d requests\n", len(players))
+// ProcessBackfill selects tickets from players to fill a backfill ticket's open slots in match. The
+// match's current players act as the anchor: candidates must pass the rules, are taken closest to the
+// match first, and must keep the whole match within the max_spread rules. Candidates must also be within
+// the max ping of the match's region, and fit the role slots the team's current players leave open. Rules
+// are evaluated with the backfill ticket's wait time. It returns nil unless every slot can be filled.
+func (m *Matchmaker) ProcessBackfill(backfill *models.MatchRequest, match *models.MultiTeamMatch, players []*models.MatchRequest, config *models.GameConfig) []*models.MatchRequest {
+	m.compileRules(config)
+	slots := backfill.Backfill.Slots
+	elapsed := time.Since(backfill.CreatedAt)
 
-		selected := make(map[string][]*models.MatchRequest) // team name -> players
-		usedInThisRound := make(map[string]bool)
+	// The match's players form a single party anchor, so spread and distance cover all of them
+	anchor := &models.MatchRequest{ID: backfill.ID, GameID: match.GameID, CreatedAt: backfill.CreatedAt}
+	for i, playerID := range m.FlattenTeams(match.Teams) {
+		if i == 0 {
+			anchor.PlayerID, anchor.Metadata = playerID, match.PlayerMetadata[playerID]
+			continue
+		}
+		anchor.Members = append(anchor.Members, models.PartyMember{PlayerID: playerID, Metadata: match.PlayerMetadata[playerID]})
+	}
 
-		// For each team, try to select enough compatible players
-		for _, team := range config.Teams {
-			available := m.getAvailablePlayers(players, usedPlayers)
-			if len(available) < team.Size {
-				fmt.Printf("[Matchmaker] Not enough available players for team %s: have %d, need %d\n", team.Name, len(available), team.Size)
-				goto done // Not enough players for this team
+	team := m.openRoles(match, config, backfill.Backfill.TeamName)
+	if slots > team.MaxPlayers()-len(match.Teams[backfill.Backfill.TeamName]) {
+		return nil // The team has fewer open seats than the ticket asks for
+	}
+	maxPing := m.ruleEngine.MaxPing(config.Latency, elapsed)
+	var candidates []*models.MatchRequest
+	for _, p := range m.ruleEngine.FindCompatiblePlayers(players, config.Rules, elapsed) {
+		if p.Backfill == nil && p.Size() <= slots && m.acceptsRegion(p, match.Region, maxPing) && m.rolesFit([]*models.MatchRequest{p}, team) {
+			candidates = append(candidates, p)
+		}
+	}
+	m.sortByDistance(anchor, candidates, config.Rules, nil, elapsed)
+
+	var selected []*models.MatchRequest
+	count := 0
+	for _, p := range candidates {
+		if count == slots {
+			break
+		}
+		if count+p.Size() > slots {
+			continue
+		}
+		group := append([]*models.MatchRequest{anchor, p}, selected...)
+		if ok, _ := m.ruleEngine.EvaluateGroup(group, config.Rules, elapsed); !ok {
+			continue
+		}
+		if !m.rolesFit(append([]*models.MatchRequest{p}, selected...), team) {
+			continue
+		}
+		selected = append(selected, p)
+		count += p.Size()
+	}
+	if count < slots {
+		return nil
+	}
+	return selected
+}
+
+// BackfillRoles assigns the players on the tickets ProcessBackfill selected to the role slots left open on
+// the backfilled team, returning player ID -> role. It is empty when the team has no role composition.
+func (m *Matchmaker) BackfillRoles(match *models.MultiTeamMatch, config *models.GameConfig, teamName string, selected []*models.MatchRequest) map[string]string {
+	return m.assignRoles(selected, m.openRoles(match, config, teamName))
+}
+
+// openRoles returns the config of a match's team with only the role slots its current players don't hold
+func (m *Matchmaker) openRoles(match *models.MultiTeamMatch, config *models.GameConfig, teamName string) models.Team {
+	var team models.Team
+	for _, t := range config.Teams {
+		if t.Name == teamName {
+			team = t
+		}
+	}
+	if len(team.Roles) == 0 {
+		return team
+	}
+
+	open := make(map[string]int, len(team.Roles))
+	for role, count := range team.Roles {
+		open[role] = count
+	}
+	for _, playerID := range match.Teams[teamName] {
+		if role, ok := match.Roles[playerID]; ok && open[role] > 0 {
+			open[role]--
+		}
+	}
+	team.Roles = open
+	return team
+}
+
+// formTeams builds the teams of a match around anchor with strategy, in config order, with the team
+// sizes of layout. Rules are evaluated with elapsed, the anchor's wait time. It returns nil if no valid
+// match can be formed around the anchor.
+func (m *Matchmaker) formTeams(anchor *models.MatchRequest, available []*models.MatchRequest, config *models.GameConfig, strategy Strategy, layout []models.Team, elapsed time.Duration) [][]*models.MatchRequest {
+	selected := m.selectCluster(anchor, available, config, strategy, layout, m.layoutSize(layout), elapsed)
+	if selected == nil {
+		return nil
+	}
+	return m.assignTeams(selected, config, strategy, layout, elapsed)
+}
+
+// spreadIndex holds a pass's tickets sorted by their position on the field of the first max_spread rule.
+// Every ticket in a match lies within the rule's spread of the anchor, so an anchor's candidates can be
+// taken from a window around it instead of the whole pool.
+type spreadIndex struct {
+	m         *Matchmaker
+	rules     []models.Rule
+	field     string
+	tickets   []*models.MatchRequest // tickets with a position on field, ascending
+	positions []float64
+	unplaced  []*models.MatchRequest // tickets without a position, which no spread rule excludes
+	order     map[string]int         // each ticket's index in the pool
+}
+
+// newSpreadIndex sorts players by their position on the field of the first max_spread rule. It returns
+// nil if the rules have no max_spread rule.
+func (m *Matchmaker) newSpreadIndex(players []*models.MatchRequest, rules []models.Rule) *spreadIndex {
+	spreadRules := m.ruleEngine.SpreadRules(rules, 0) // relaxation only widens or drops a rule
+	if len(spreadRules) == 0 {
+		return nil
+	}
+
+	index := &spreadIndex{m: m, rules: rules, field: spreadRules[0].Field, order: make(map[string]int, len(players))}
+	positions := make(map[string]float64, len(players))
+	for i, player := range players {
+		index.order[player.ID] = i
+		if position, ok := m.ruleEngine.Position(player, index.field); ok {
+			positions[player.ID] = position
+			index.tickets = append(index.tickets, player)
+		} else {
+			index.unplaced = append(index.unplaced, player)
+		}
+	}
+	sort.SliceStable(index.tickets, func(i, j int) bool {
+		return positions[index.tickets[i].ID] < positions[index.tickets[j].ID]
+	})
+	index.positions = make([]float64, len(index.tickets))
+	for i, ticket := range index.tickets {
+		index.positions[i] = positions[ticket.ID]
+	}
+	return index
+}
+
+// window returns the unused tickets within the index field's spread of anchor after elapsed, plus those
+// without a position, in pool order. It returns available when the index doesn't narrow the pool: there
+// is no index, the field's rule is relaxed away, or the anchor has no position.
+func (index *spreadIndex) window(anchor *models.MatchRequest, available []*models.MatchRequest, usedPlayers map[string]bool, elapsed time.Duration) []*models.MatchRequest {
+	if index == nil {
+		return available
+	}
+	var maxSpread *float64
+	for _, rule := range index.m.ruleEngine.SpreadRules(index.rules, elapsed) {
+		if rule.Field == index.field && (maxSpread == nil || *rule.MaxSpread < *maxSpread) {
+			maxSpread = rule.MaxSpread
+		}
+	}
+	position, ok := index.m.ruleEngine.Position(anchor, index.field)
+	if maxSpread == nil || !ok {
+		return available
+	}
+
+	lo := sort.SearchFloat64s(index.positions, position-*maxSpread)
+	hi := sort.Search(len(index.positions), func(i int) bool {
+		return index.positions[i] > position+*maxSpread
+	})
+	var window []*models.MatchRequest
+	for _, ticket := range append(index.tickets[lo:hi:hi], index.unplaced...) {
+		if !usedPlayers[ticket.ID] {
+			window = append(window, ticket)
+		}
+	}
+	sort.Slice(window, func(i, j int) bool {
+		return index.order[window[i].ID] < index.order[window[j].ID]
+	})
+	return window
+}
+
+// pastFillDeadline reports whether anchor has waited long enough for a match to launch with short teams
+func (m *Matchmaker) pastFillDeadline(anchor *models.MatchRequest, config *models.GameConfig) bool {
+	return config.FillDeadline > 0 && time.Since(anchor.CreatedAt) >= time.Duration(config.FillDeadline)*time.Second
+}
+
+// teamLayout returns a copy of teamConfigs with each team's Size set to sizes, or to its max size
+// when sizes is nil
+func (m *Matchmaker) teamLayout(teamConfigs []models.Team, sizes []int) []models.Team {
+	layout := make([]models.Team, len(teamConfigs))
+	for i, team := range teamConfigs {
+		layout[i] = team
+		layout[i].Size = team.MaxPlayers()
+		if sizes != nil {
+			layout[i].Size = sizes[i]
+		}
+	}
+	return layout
+}
+
+// shortLayouts returns every short-handed layout of the teams, largest first: each team holds between
+// its min and max size, at least one team is short, and no team is more than one player shorter than
+// another.
+func (m *Matchmaker) shortLayouts(teamConfigs []models.Team) [][]models.Team {
+	var layouts [][]models.Team
+	sizes := make([]int, len(teamConfigs))
+
+	var enumerate func(i int)
+	enumerate = func(i int) {
+		if i == len(teamConfigs) {
+			fewest, most := math.MaxInt, 0 // players short of a full team
+			for t, team := range teamConfigs {
+				short := team.MaxPlayers() - sizes[t]
+				fewest = min(fewest, short)
+				most = max(most, short)
+			}
+			if most > 0 && most-fewest <= 1 {
+				layouts = append(layouts, m.teamLayout(teamConfigs, append([]int(nil), sizes...)))
 			}
+			return
+		}
+		for size := teamConfigs[i].MaxPlayers(); size >= teamConfigs[i].MinPlayers(); size-- {
+			sizes[i] = size
+			enumerate(i + 1)
+		}
+	}
+	enumerate(0)
+
+	sort.SliceStable(layouts, func(i, j int) bool {
+		return m.layoutSize(layouts[i]) > m.layoutSize(layouts[j])
+	})
+	return layouts
+}
+
+// layoutSize returns the number of players across a layout's teams
+func (m *Matchmaker) layoutSize(layout []models.Team) int {
+	size := 0
+	for _, team := range layout {
+		size += team.Size
+	}
+	return size
+}
+
+// selectCluster selects compatible tickets around anchor until they hold size players, anchor first
+// and then in the order strategy ranks them. Tickets are only taken if every selected ticket still
+// fits into the teams and their role slots. It returns nil if the anchor itself fails the rules or
+// not enough tickets fit together.
+func (m *Matchmaker) selectCluster(anchor *models.MatchRequest, available []*models.MatchRequest, config *models.GameConfig, strategy Strategy, teamConfigs []models.Team, size int, elapsed time.Duration) []*models.MatchRequest {
+	rules := config.Rules
+	if ok, _ := m.ruleEngine.EvaluatePlayer(anchor, rules, elapsed); !ok {
+		return nil
+	}
+	if _, ok := m.selectRegion([]*models.MatchRequest{anchor}, config, elapsed); !ok {
+		return nil // No region is within the max ping for the anchor's players
+	}
+	if m.packTeams([]*models.MatchRequest{anchor}, teamConfigs) == nil {
+		return nil // The party is larger than every team
+	}
+
+	var candidates []*models.MatchRequest
+	for _, p := range m.ruleEngine.FindCompatiblePlayers(available, rules, elapsed) {
+		if p.ID != anchor.ID {
+			candidates = append(candidates, p)
+		}
+	}
+	if m.countPlayers(candidates) < size-anchor.Size() {
+		return nil
+	}
+
+	roles := m.roleNames(teamConfigs)
+	strategy.Rank(anchor, candidates, config, elapsed)
+
+	selected := []*models.MatchRequest{anchor}
+	count := anchor.Size()
+	for _, p := range candidates {
+		if count == size {
+			break
+		}
+		if count+p.Size() > size {
+			continue
+		}
+		group := append(selected, p)
+		if ok, _ := m.ruleEngine.EvaluateGroup(group, rules, elapsed); !ok {
+			continue
+		}
+		if _, ok := m.selectRegion(group, config, elapsed); !ok {
+			continue
+		}
+		if (p.Size() > 1 || len(roles) > 0) && m.packTeams(group, teamConfigs) == nil {
+			continue
+		}
+		selected = group
+		count += p.Size()
+	}
+	if count < size || m.packTeams(selected, teamConfigs) == nil {
+		return nil
+	}
+	return selected
+}
 
-			// Find compatible players for this team
-			oldest := m.findOldestPlayer(available)
-			elapsed := time.Since(oldest.CreatedAt)
-			compatible := m.ruleEngine.FindCompatiblePlayers(available, config.Rules, elapsed)
+// sortByDistance orders candidates closest to anchor first; ties go to the players who accept the fewest
+// of roles, then to the longest waiting
+func (m *Matchmaker) sortByDistance(anchor *models.MatchRequest, candidates []*models.MatchRequest, rules []models.Rule, roles []string, elapsed time.Duration) {
+	type rankedTicket struct {
+		ticket    *models.MatchRequest
+		distance  float64
+		roleCount int
+	}
+	ranked := make([]rankedTicket, len(candidates))
+	for i, p := range candidates {
+		ranked[i] = rankedTicket{p, m.ruleEngine.Distance(anchor, p, rules, elapsed), m.roleCount(p.MemberRequests(), roles)}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].distance != ranked[j].distance {
+			return ranked[i].distance < ranked[j].distance
+		}
+		if ranked[i].roleCount != ranked[j].roleCount {
+			return ranked[i].roleCount < ranked[j].roleCount
+		}
+		return ranked[i].ticket.CreatedAt.Before(ranked[j].ticket.CreatedAt)
+	})
+	for i, r := range ranked {
+		candidates[i] = r.ticket
+	}
+}
+
+// assignTeams splits the selected tickets into the layout's teams with strategy. While the team rules
+// fail, it swaps the pair of same-sized tickets between two teams that brings the team aggregates
+// closest to tolerance, skipping swaps that leave a team unable to fill its role slots.
+// It returns nil if the strategy's split is invalid or the team rules cannot be satisfied.
+func (m *Matchmaker) assignTeams(selected []*models.MatchRequest, config *models.GameConfig, strategy Strategy, layout []models.Team, elapsed time.Duration) [][]*models.MatchRequest {
+	teams := strategy.Split(selected, layout, config)
+	if !m.validSplit(teams, selected, layout) {
+		return nil
+	}
 
-			// Remove already selected in this round
-			var filtered []*models.MatchRequest
-			for _, p := range compatible {
-				if !usedInThisRound[p.ID] {
-					filtered = append(filtered, p)
+	violation := m.ruleEngine.TeamViolation(teams, config.TeamRules, elapsed)
+	for violation > 0 {
+		best := violation
+		bestA, bestI, bestB, bestJ := -1, 0, 0, 0
+		for a := range teams {
+			for b := a + 1; b < len(teams); b++ {
+				for i := range teams[a] {
+					for j := range teams[b] {
+						if teams[a][i].Size() != teams[b][j].Size() {
+							continue
+						}
+						teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+						if !m.rolesFit(teams[a], layout[a]) || !m.rolesFit(teams[b], layout[b]) {
+							teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+							continue
+						}
+						if v := m.ruleEngine.TeamViolation(teams, config.TeamRules, elapsed); v < best {
+							best = v
+							bestA, bestI, bestB, bestJ = a, i, b, j
+						}
+						teams[a][i], teams[b][j] = teams[b][j], teams[a][i]
+					}
 				}
 			}
+		}
 
-			if len(filtered) < team.Size {
-				fmt.Printf("[Matchmaker] Not enough compatible players for team %s after filtering: have %d, need %d\n", team.Name, len(filtered), team.Size)
-				goto done // Not enough compatible players for this team
-			}
+		if bestA < 0 {
+			return nil // No swap improves the teams
+		}
+		teams[bestA][bestI], teams[bestB][bestJ] = teams[bestB][bestJ], teams[bestA][bestI]
+		violation = best
+	}
+
+	return teams
+}
 
-			// Select the best players for this team
-			selectedPlayers := m.selectBestPlayers(filtered, team.Size, oldest.CreatedAt)
-			selected[team.Name] = selectedPlayers
-			for _, p := range selectedPlayers {
-				usedInThisRound[p.ID] = true
+// validSplit reports whether teams holds every selected ticket exactly once, each team filled to its
+// layout size with its role slots covered
+func (m *Matchmaker) validSplit(teams [][]*models.MatchRequest, selected []*models.MatchRequest, layout []models.Team) bool {
+	if len(teams) != len(layout) {
+		return false
+	}
+	remaining := make(map[string]bool, len(selected))
+	for _, ticket := range selected {
+		remaining[ticket.ID] = true
+	}
+	for t, team := range teams {
+		for _, ticket := range team {
+			if !remaining[ticket.ID] {
+				return false
 			}
+			delete(remaining, ticket.ID)
 		}
+		if m.countPlayers(team) != layout[t].Size || !m.rolesFit(team, layout[t]) {
+			return false
+		}
+	}
+	return len(remaining) == 0
+}
+
+// splitTeams partitions tickets across the layout's teams to equalise the team totals of field, if set
+// and possible; otherwise teams are filled in ticket order, so the anchor and the best ranked tickets
+// share the first team. It returns nil if the tickets can't be packed.
+func (m *Matchmaker) splitTeams(tickets []*models.MatchRequest, layout []models.Team, field string) [][]*models.MatchRequest {
+	if field != "" {
+		if teams := m.balanceTeams(tickets, layout, field); teams != nil {
+			return teams
+		}
+	}
+	assignment := m.packTeams(tickets, layout)
+	if assignment == nil {
+		return nil
+	}
+	return m.groupTeams(tickets, assignment, len(layout))
+}
+
+// packTeams assigns tickets in order to the first team with room and open role slots for their
+// players, backtracking when a later ticket doesn't fit. It returns the team index for each ticket,
+// or nil if the tickets can't be packed.
+func (m *Matchmaker) packTeams(tickets []*models.MatchRequest, teamConfigs []models.Team) []int {
+	assignment := make([]int, len(tickets))
+	counts := make([]int, len(teamConfigs))
+	members := make([][]*models.MatchRequest, len(teamConfigs))
 
-		// If we get here, all teams are filled - create a single match with all players and request IDs
-		allPlayerIDs := make([]string, 0)
-		allRequestIDs := make([]string, 0)
-		playerIDSet := make(map[string]bool)
-		for _, reqs := range selected {
-			for _, req := range reqs {
-				usedPlayers[req.ID] = true // Mark this player as used
-				if !playerIDSet[req.PlayerID] {
-					allPlayerIDs = append(allPlayerIDs, req.PlayerID)
-					playerIDSet[req.PlayerID] = true
+	var pack func(i int) bool
+	pack = func(i int) bool {
+		if i == len(tickets) {
+			return true
+		}
+		for t, team := range teamConfigs {
+			if counts[t]+tickets[i].Size() > team.Size {
+				continue
+			}
+			members[t] = append(members[t], tickets[i])
+			if m.rolesFit(members[t], team) {
+				assignment[i] = t
+				counts[t] += tickets[i].Size()
+				if pack(i + 1) {
+					return true
 				}
-				allRequestIDs = append(allRequestIDs, req.ID)
+				counts[t] -= tickets[i].Size()
 			}
+			members[t] = members[t][:len(members[t])-1]
 		}
-		fmt.Printf("[Matchmaker] Forming match with unique player IDs: %v\n", allPlayerIDs)
+		return false
+	}
 
-		// Create a single match containing all players from all teams
-		firstTeamName := config.Teams[0].Name
-		match := models.NewMatch(config.GameID, firstTeamName, allPlayerIDs)
-		results = append(results, MatchWithRequests{
-			Match:      match,
-			RequestIDs: allRequestIDs,
-		})
+	if !pack(0) {
+		return nil
 	}
-done:
-	return results
+	return assignment
 }
 
-// ProcessFullTeamMatchPool processes a pool of players and forms matches only when all teams can be filled
-// This is now the same as ProcessMatchPool - kept for backward compatibility
-func (m *Matchmaker) ProcessFullTeamMatchPool(players []*models.MatchRequest, config *models.GameConfig) []*models.MultiTeamMatch {
-	fmt.Printf("[MM] Starting ProcessFullTeamMatchPool: %d players, %d teams\n", len(players), len(config.Teams))
-	var matches []*models.MultiTeamMatch
-	usedPlayers := make(map[string]bool)
-	teamCount := len(config.Teams)
-	if teamCount == 0 {
-		fmt.Println("[MM] No teams in config, aborting.")
-		return matches
+// groupTeams groups tickets by their assigned team index
+func (m *Matchmaker) groupTeams(tickets []*models.MatchRequest, assignment []int, teamCount int) [][]*models.MatchRequest {
+	teams := make([][]*models.MatchRequest, teamCount)
+	for i, ticket := range tickets {
+		teams[assignment[i]] = append(teams[assignment[i]], ticket)
 	}
-	// Continue forming matches while all teams can be filled
-	for {
-		selected := make(map[string][]*models.MatchRequest) // team name -> players
-		usedInThisRound := make(map[string]bool)
-		// For each team, try to select enough compatible players
-		for _, team := range config.Teams {
-			available := m.getAvailablePlayers(players, usedPlayers)
-			fmt.Printf("[MM] Team %s: need %d, available %d\n", team.Name, team.Size, len(available))
-			if len(available) < team.Size {
-				fmt.Printf("[MM] Not enough players for team %s: have %d, need %d\n", team.Name, len(available), team.Size)
-				goto done // Not enough players for this team
+	return teams
+}
+
+// exhaustiveBalanceLimit is the largest match, in tickets, that balanceTeams partitions exhaustively
+const exhaustiveBalanceLimit = 10
+
+// balanceTeams partitions tickets across teams to minimise the gap between the highest and lowest
+// team total of field. Small matches try every partition; larger ones assign tickets from the
+// highest total down to the team with the lowest total that still has room. It returns nil if
+// the greedy assignment can't fit every party or role slot.
+func (m *Matchmaker) balanceTeams(tickets []*models.MatchRequest, teamConfigs []models.Team, field string) [][]*models.MatchRequest {
+	values := make([]float64, len(tickets))
+	sizes := make([]int, len(tickets))
+	for i, ticket := range tickets {
+		values[i] = m.ticketTotal(ticket, field)
+		sizes[i] = ticket.Size()
+	}
+
+	fits := func(assignment []int) bool {
+		for t, team := range m.groupTeams(tickets, assignment, len(teamConfigs)) {
+			if !m.rolesFit(team, teamConfigs[t]) {
+				return false
 			}
-			// Find compatible players for this team
-			oldest := m.findOldestPlayer(available)
-			elapsed := time.Since(oldest.CreatedAt)
-			compatible := m.ruleEngine.FindCompatiblePlayers(available, config.Rules, elapsed)
-			fmt.Printf("[MM] Team %s: compatible %d\n", team.Name, len(compatible))
-			// Remove already selected in this round
-			var filtered []*models.MatchRequest
-			for _, p := range compatible {
-				if !usedInThisRound[p.ID] {
-					filtered = append(filtered, p)
-				}
+		}
+		return true
+	}
+
+	var assignment []int // ticket index -> team index
+	if len(tickets) <= exhaustiveBalanceLimit {
+		assignment = m.balanceExhaustive(values, sizes, teamConfigs, fits)
+	} else {
+		assignment = m.balanceGreedy(values, sizes, teamConfigs)
+	}
+	if assignment == nil || !fits(assignment) {
+		return nil
+	}
+
+	return m.groupTeams(tickets, assignment, len(teamConfigs))
+}
+
+// balanceExhaustive tries every assignment of values to teams and returns the one accepted by fits with
+// the smallest gap between team totals, or nil if none fits. Tickets are assigned in order, so ties keep
+// earlier tickets in earlier teams.
+func (m *Matchmaker) balanceExhaustive(values []float64, sizes []int, teamConfigs []models.Team, fits func([]int) bool) []int {
+	assignment := make([]int, len(values))
+	var best []int
+	bestGap := math.Inf(1)
+	totals := make([]float64, len(teamConfigs))
+	counts := make([]int, len(teamConfigs))
+
+	var assign func(i int)
+	assign = func(i int) {
+		if i == len(values) {
+			if gap := m.totalsGap(totals); gap < bestGap && fits(assignment) {
+				bestGap = gap
+				best = append(best[:0], assignment...)
 			}
-			fmt.Printf("[MM] Team %s: filtered %d\n", team.Name, len(filtered))
-			if len(filtered) < team.Size {
-				fmt.Printf("[MM] Not enough compatible players for team %s after filtering: have %d, need %d\n", team.Name, len(filtered), team.Size)
-				goto done // Not enough compatible players for this team
+			return
+		}
+		for t, team := range teamConfigs {
+			if counts[t]+sizes[i] > team.Size {
+				continue
 			}
-			// Select the best players for this team
-			selectedPlayers := m.selectBestPlayers(filtered, team.Size, oldest.CreatedAt)
-			selected[team.Name] = selectedPlayers
-			for _, p := range selectedPlayers {
-				usedInThisRound[p.ID] = true
+			assignment[i] = t
+			totals[t] += values[i]
+			counts[t] += sizes[i]
+			assign(i + 1)
+			totals[t] -= values[i]
+			counts[t] -= sizes[i]
+		}
+	}
+	assign(0)
+
+	return best
+}
+
+// balanceGreedy assigns values from highest to lowest, each to the team with the lowest total that has
+// room. It returns nil if a ticket doesn't fit any team.
+func (m *Matchmaker) balanceGreedy(values []float64, sizes []int, teamConfigs []models.Team) []int {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return values[order[a]] > values[order[b]]
+	})
+
+	assignment := make([]int, len(values))
+	totals := make([]float64, len(teamConfigs))
+	counts := make([]int, len(teamConfigs))
+	for _, i := range order {
+		target := -1
+		for t, team := range teamConfigs {
+			if counts[t]+sizes[i] <= team.Size && (target < 0 || totals[t] < totals[target]) {
+				target = t
 			}
 		}
-		// If we get here, all teams are filled
-		teamMap := make(map[string][]string)
-		for teamName, reqs := range selected {
-			for _, req := range reqs {
-				usedPlayers[req.ID] = true
-				teamMap[teamName] = append(teamMap[teamName], req.PlayerID)
+		if target < 0 {
+			return nil
+		}
+		assignment[i] = target
+		totals[target] += values[i]
+		counts[target] += sizes[i]
+	}
+
+	return assignment
+}
+
+// totalsGap returns the difference between the highest and lowest team total
+func (m *Matchmaker) totalsGap(totals []float64) float64 {
+	lowest, highest := totals[0], totals[0]
+	for _, total := range totals[1:] {
+		lowest = math.Min(lowest, total)
+		highest = math.Max(highest, total)
+	}
+	return highest - lowest
+}
+
+// teamTotals returns each team's total of field, keyed by team name
+func (m *Matchmaker) teamTotals(teams [][]*models.MatchRequest, teamConfigs []models.Team, field string) map[string]float64 {
+	totals := make(map[string]float64, len(teamConfigs))
+	for i, team := range teamConfigs {
+		totals[team.Name] = 0
+		for _, ticket := range teams[i] {
+			totals[team.Name] += m.ticketTotal(ticket, field)
+		}
+	}
+	return totals
+}
+
+// ticketTotal returns the total of field over every player on a ticket
+func (m *Matchmaker) ticketTotal(ticket *models.MatchRequest, field string) float64 {
+	var total float64
+	for _, member := range ticket.MemberRequests() {
+		value, _ := m.ruleEngine.FieldValue(member.Metadata, field)
+		number, _ := m.ruleEngine.NumericValue(value)
+		total += number
+	}
+	return total
+}
+
+// hasRoles reports whether any team has a role composition
+func (m *Matchmaker) hasRoles(teamConfigs []models.Team) bool {
+	return len(m.roleNames(teamConfigs)) > 0
+}
+
+// roleNames returns the sorted names of every role across the teams' compositions
+func (m *Matchmaker) roleNames(teamConfigs []models.Team) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, team := range teamConfigs {
+		for role := range team.Roles {
+			if !seen[role] {
+				seen[role] = true
+				names = append(names, role)
 			}
 		}
-		fmt.Printf("[MM] Forming match: %v\n", teamMap)
-		match := &models.MultiTeamMatch{
-			ID:        models.NewMatch(config.GameID, "multi", nil).ID, // reuse uuid
-			GameID:    config.GameID,
-			Teams:     teamMap,
-			CreatedAt: time.Now(),
+	}
+	sort.Strings(names)
+	return names
+}
+
+// acceptsRole reports whether a player is willing to play role. A player who declared no roles accepts any.
+func (m *Matchmaker) acceptsRole(player *models.MatchRequest, role string) bool {
+	declared := player.Roles()
+	if len(declared) == 0 {
+		return true
+	}
+	for _, r := range declared {
+		if r == role {
+			return true
 		}
-		matches = append(matches, match)
 	}
-done:
-	fmt.Printf("[MM] Done. Formed %d matches.\n", len(matches))
-	return matches
+	return false
 }
 
-// getAvailablePlayers returns players that haven't been used in matches yet
-func (m *Matchmaker) getAvailablePlayers(players []*models.MatchRequest, usedPlayers map[string]bool) []*models.MatchRequest {
-	var available []*models.MatchRequest
+// roleCount returns how many of roles the players accept, summed over the players
+func (m *Matchmaker) roleCount(players []*models.MatchRequest, roles []string) int {
+	count := 0
 	for _, player := range players {
-		if !usedPlayers[player.ID] {
-			available = append(available, player)
+		for _, role := range roles {
+			if m.acceptsRole(player, role) {
+				count++
+			}
 		}
 	}
-	return available
+	return count
 }
 
-// findOldestPlayer finds the player who has been waiting the longest
-func (m *Matchmaker) findOldestPlayer(players []*models.MatchRequest) *models.MatchRequest {
-	if len(players) == 0 {
+// rolesFit reports whether every player on a team's tickets can be given one of its open role slots
+func (m *Matchmaker) rolesFit(tickets []*models.MatchRequest, team models.Team) bool {
+	return len(team.Roles) == 0 || m.assignRoles(tickets, team) != nil
+}
+
+// assignRoles fills a team's role slots with the players on its tickets, returning player ID -> role.
+// Players who declared fewer roles are placed first, so flexible players take the slots left over.
+// It returns nil if some player can't be given a slot they accept, and an empty map for a team
+// without a role composition.
+func (m *Matchmaker) assignRoles(tickets []*models.MatchRequest, team models.Team) map[string]string {
+	roles := make(map[string]string)
+	if len(team.Roles) == 0 {
+		return roles
+	}
+
+	var players []*models.MatchRequest
+	for _, ticket := range tickets {
+		players = append(players, ticket.MemberRequests()...)
+	}
+	var slots []string
+	names := m.roleNames([]models.Team{team})
+	for _, role := range names {
+		for i := 0; i < team.Roles[role]; i++ {
+			slots = append(slots, role)
+		}
+	}
+	if len(players) > len(slots) {
 		return nil
 	}
 
-	oldest := players[0]
-	for _, player := range players {
-		if player.CreatedAt.Before(oldest.CreatedAt) {
-			oldest = player
+	order := make([]int, len(players))
+	roleCounts := make([]int, len(players))
+	for i, player := range players {
+		order[i] = i
+		roleCounts[i] = m.roleCount([]*models.MatchRequest{player}, names)
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return roleCounts[order[a]] < roleCounts[order[b]]
+	})
+
+	// Bipartite matching of players to slots: a player takes a free slot they accept, or one whose
+	// holder can move to another slot
+	holders := make([]int, len(slots))
+	for s := range holders {
+		holders[s] = -1
+	}
+	var place func(p int, visited []bool) bool
+	place = func(p int, visited []bool) bool {
+		for s, role := range slots {
+			if visited[s] || !m.acceptsRole(players[p], role) {
+				continue
+			}
+			visited[s] = true
+			if holders[s] < 0 || place(holders[s], visited) {
+				holders[s] = p
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range order {
+		if !place(p, make([]bool, len(slots))) {
+			return nil
+		}
+	}
+
+	for s, p := range holders {
+		if p >= 0 {
+			roles[players[p].PlayerID] = slots[s]
+		}
+	}
+	return roles
+}
+
+// selectRegion returns the region that minimises the worst ping among the players on tickets, ties going
+// to the lower total ping, then the region name. Only regions every player with pings has measured are
+// considered, and only those where no player exceeds the config's max ping after elapsed. It returns ""
+// if no player has pings, and false if no region qualifies.
+func (m *Matchmaker) selectRegion(tickets []*models.MatchRequest, config *models.GameConfig, elapsed time.Duration) (string, bool) {
+	var players []*models.MatchRequest
+	for _, ticket := range tickets {
+		for _, member := range ticket.MemberRequests() {
+			if len(member.Latencies) > 0 {
+				players = append(players, member)
+			}
+		}
+	}
+	if len(players) == 0 {
+		return "", true
+	}
+
+	maxPing := m.ruleEngine.MaxPing(config.Latency, elapsed)
+	best, bestWorst, bestTotal := "", 0, 0
+	for region := range players[0].Latencies {
+		worst, total := 0, 0
+		for _, player := range players {
+			ping, ok := player.Latencies[region]
+			if !ok || (maxPing > 0 && ping > maxPing) {
+				worst = -1
+				break
+			}
+			worst = max(worst, ping)
+			total += ping
+		}
+		if worst < 0 {
+			continue
+		}
+		if best == "" || worst < bestWorst || (worst == bestWorst && (total < bestTotal || (total == bestTotal && region < best))) {
+			best, bestWorst, bestTotal = region, worst, total
 		}
 	}
-	return oldest
+	return best, best != ""
 }
 
-// selectBestPlayers selects the best players for a team based on wait time
-func (m *Matchmaker) selectBestPlayers(players []*models.MatchRequest, teamSize int, referenceTime time.Time) []*models.MatchRequest {
-	// Sort players by wait time (longest waiting first)
-	sortedPlayers := make([]*models.MatchRequest, len(players))
-	copy(sortedPlayers, players)
-	sort.Slice(sortedPlayers, func(i, j int) bool {
-		return sortedPlayers[i].CreatedAt.Before(sortedPlayers[j].CreatedAt)
+// acceptsRegion reports whether every player on ticket with pings has measured region, within maxPing if
+// set. Any ticket accepts a match without a region.
+func (m *Matchmaker) acceptsRegion(ticket *models.MatchRequest, region string, maxPing int) bool {
+	if region == "" {
+		return true
+	}
+	for _, member := range ticket.MemberRequests() {
+		if len(member.Latencies) == 0 {
+			continue
+		}
+		ping, ok := member.Latencies[region]
+		if !ok || (maxPing > 0 && ping > maxPing) {
+			return false
+		}
+	}
+	return true
+}
+
+// countPlayers returns the number of players across tickets
+func (m *Matchmaker) countPlayers(tickets []*models.MatchRequest) int {
+	count := 0
+	for _, ticket := range tickets {
+		count += ticket.Size()
+	}
+	return count
+}
+
+// sortByWaitTime returns players ordered from longest to shortest waiting
+func (m *Matchmaker) sortByWaitTime(players []*models.MatchRequest) []*models.MatchRequest {
+	sorted := make([]*models.MatchRequest, len(players))
+	copy(sorted, players)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
 	})
+	return sorted
+}
 
-	// Return the first N players (where N is team size)
-	if len(sortedPlayers) > teamSize {
-		return sortedPlayers[:teamSize]
+// waitTime returns how long the longest-waiting of tickets has waited
+func (m *Matchmaker) waitTime(tickets []*models.MatchRequest) time.Duration {
+	var longest time.Duration
+	for _, ticket := range tickets {
+		longest = max(longest, time.Since(ticket.CreatedAt))
 	}
-	return sortedPlayers
+	return longest
+}
+
+// getAvailablePlayers returns players that haven't been used in matches yet
+func (m *Matchmaker) getAvailablePlayers(players []*models.MatchRequest, usedPlayers map[string]bool) []*models.MatchRequest {
+	var available []*models.MatchRequest
+	for _, player := range players {
+		if !usedPlayers[player.ID] {
+			available = append(available, player)
+		}
+	}
+	return available
 }
 
 // getPlayerIDs extracts player IDs from a slice of match requests
@@ -320,8 +1078,11 @@ func (m *Matchmaker) ValidateMatch(match *models.Match, config *models.GameConfi
 		return fmt.Errorf("team '%s' not found in game configuration", match.TeamName)
 	}
 
-	if len(match.Players) != teamConfig.Size {
-		return fmt.Errorf("team '%s' requires %d players, got %d", match.TeamName, teamConfig.Size, len(match.Players))
+	if len(match.Players) < teamConfig.MinPlayers() || len(match.Players) > teamConfig.MaxPlayers() {
+		if teamConfig.MinPlayers() == teamConfig.MaxPlayers() {
+			return fmt.Errorf("team '%s' requires %d players, got %d", match.TeamName, teamConfig.MaxPlayers(), len(match.Players))
+		}
+		return fmt.Errorf("team '%s' requires %d to %d players, got %d", match.TeamName, teamConfig.MinPlayers(), teamConfig.MaxPlayers(), len(match.Players))
 	}
 
 	return nil
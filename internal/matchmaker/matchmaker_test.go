@@ -1,11 +1,16 @@
 package matchmaker
 
 import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/mm-rules/matchmaking/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewMatchmaker(t *testing.T) {
@@ -22,7 +27,7 @@ func TestMatchmaker_ProcessMatchPool_Success(t *testing.T) {
 		Teams: []models.Team{
 			{Name: "team1", Size: 2},
 		},
-		Rules: []models.Rule{{Field: "level", Min: &[]int{10}[0]}},
+		Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
 	}
 
 	players := []*models.MatchRequest{
@@ -60,7 +65,7 @@ func TestMatchmaker_ProcessMatchPool_NoPlayers(t *testing.T) {
 		Teams: []models.Team{
 			{Name: "team1", Size: 2},
 		},
-		Rules: []models.Rule{{Field: "level", Min: &[]int{10}[0]}},
+		Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
 	}
 
 	players := []*models.MatchRequest{}
@@ -78,7 +83,7 @@ func TestMatchmaker_ProcessMatchPool_NotEnoughPlayers(t *testing.T) {
 		Teams: []models.Team{
 			{Name: "team1", Size: 3},
 		},
-		Rules: []models.Rule{{Field: "level", Min: &[]int{10}[0]}},
+		Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0]}},
 	}
 
 	players := []*models.MatchRequest{
@@ -167,6 +172,29 @@ func TestMatchmaker_ValidateMatch_WrongTeamSize(t *testing.T) {
 	assert.Contains(t, err.Error(), "team 'team1' requires 3 players, got 2")
 }
 
+func TestMatchmaker_ValidateMatch_FlexibleTeamSize(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "test-game",
+		Teams: []models.Team{
+			{Name: "team1", MinSize: 2, MaxSize: 3},
+		},
+	}
+
+	match := &models.Match{
+		GameID:   "test-game",
+		TeamName: "team1",
+		Players:  []string{"player1", "player2"},
+	}
+	assert.NoError(t, matchmaker.ValidateMatch(match, config))
+
+	match.Players = []string{"player1"}
+	err := matchmaker.ValidateMatch(match, config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "team 'team1' requires 2 to 3 players, got 1")
+}
+
 func TestMatchmaker_GetMatchStats(t *testing.T) {
 	matchmaker := NewMatchmaker()
 
@@ -220,7 +248,7 @@ func TestMatchmaker_ProcessFullTeamMatchPool_1v1_Success(t *testing.T) {
 			{Name: "Player2", Size: 1},
 		},
 		Rules: []models.Rule{
-			{Field: "level", Min: &[]int{10}[0], Max: &[]int{50}[0], Strict: false},
+			{Field: "level", Min: &[]float64{10}[0], Max: &[]float64{50}[0], Strict: false},
 		},
 	}
 
@@ -272,7 +300,7 @@ func TestMatchmaker_ProcessFullTeamMatchPool_1v1_NotEnoughPlayers(t *testing.T)
 			{Name: "Player2", Size: 1},
 		},
 		Rules: []models.Rule{
-			{Field: "level", Min: &[]int{10}[0], Max: &[]int{50}[0], Strict: false},
+			{Field: "level", Min: &[]float64{10}[0], Max: &[]float64{50}[0], Strict: false},
 		},
 	}
 
@@ -305,7 +333,7 @@ func TestMatchmaker_ProcessFullTeamMatchPool_1v3_Success(t *testing.T) {
 			{Name: "Trio", Size: 3},
 		},
 		Rules: []models.Rule{
-			{Field: "level", Min: &[]int{15}[0], Max: &[]int{60}[0], Strict: false},
+			{Field: "level", Min: &[]float64{15}[0], Max: &[]float64{60}[0], Strict: false},
 		},
 	}
 
@@ -375,7 +403,7 @@ func TestMatchmaker_ProcessFullTeamMatchPool_MultipleMatches(t *testing.T) {
 			{Name: "Player2", Size: 1},
 		},
 		Rules: []models.Rule{
-			{Field: "level", Min: &[]int{10}[0], Max: &[]int{50}[0], Strict: false},
+			{Field: "level", Min: &[]float64{10}[0], Max: &[]float64{50}[0], Strict: false},
 		},
 	}
 
@@ -443,3 +471,722 @@ func TestMatchmaker_ProcessFullTeamMatchPool_MultipleMatches(t *testing.T) {
 	assert.True(t, allPlayers["player3"])
 	assert.True(t, allPlayers["player4"])
 }
+
+func TestMatchmaker_ProcessFullTeamMatchPool_ClustersAroundAnchor(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-1v1",
+		Teams: []models.Team{
+			{Name: "Player1", Size: 1},
+			{Name: "Player2", Size: 1},
+		},
+		Rules: []models.Rule{
+			{Field: "skill_rating", MaxSpread: &[]float64{150}[0], Strict: true},
+		},
+	}
+
+	now := time.Now()
+	players := []*models.MatchRequest{
+		{ID: "req1", PlayerID: "anchor", Metadata: map[string]interface{}{"skill_rating": 1500}, CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "req2", PlayerID: "low", Metadata: map[string]interface{}{"skill_rating": 1000}, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "req3", PlayerID: "close", Metadata: map[string]interface{}{"skill_rating": 1560}, CreatedAt: now},
+		{ID: "req4", PlayerID: "closest", Metadata: map[string]interface{}{"skill_rating": 1480}, CreatedAt: now.Add(-time.Minute)},
+	}
+
+	matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+	// The anchor is paired with the closest rating rather than the longest-waiting player,
+	// and the remaining players are too far apart to be matched
+	assert.Len(t, matches, 1)
+	assert.Equal(t, []string{"anchor"}, matches[0].Teams["Player1"])
+	assert.Equal(t, []string{"closest"}, matches[0].Teams["Player2"])
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_SkipsUnmatchableAnchor(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-2v2",
+		Teams: []models.Team{
+			{Name: "red", Size: 2},
+			{Name: "blue", Size: 2},
+		},
+		Rules: []models.Rule{
+			{Field: "skill_rating", MaxSpread: &[]float64{100}[0], Strict: true},
+		},
+	}
+
+	now := time.Now()
+	players := []*models.MatchRequest{
+		{ID: "req1", PlayerID: "outlier", Metadata: map[string]interface{}{"skill_rating": 3000}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "req2", PlayerID: "p2", Metadata: map[string]interface{}{"skill_rating": 1200}, CreatedAt: now.Add(-4 * time.Minute)},
+		{ID: "req3", PlayerID: "p3", Metadata: map[string]interface{}{"skill_rating": 1150}, CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "req4", PlayerID: "p4", Metadata: map[string]interface{}{"skill_rating": 1240}, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "req5", PlayerID: "p5", Metadata: map[string]interface{}{"skill_rating": 1100}, CreatedAt: now.Add(-time.Minute)},
+		{ID: "req6", PlayerID: "p6", Metadata: map[string]interface{}{"skill_rating": 1210}, CreatedAt: now},
+	}
+
+	matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+	// The outlier can't be matched, so the next oldest player anchors a match whose
+	// ratings stay within 100 of each other
+	assert.Len(t, matches, 1)
+	matched := matchmaker.FlattenTeams(matches[0].Teams)
+	assert.ElementsMatch(t, []string{"p2", "p3", "p4", "p6"}, matched)
+	assert.Contains(t, matches[0].Teams["red"], "p2")
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_TeamRulesReshuffle(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-2v2",
+		Teams: []models.Team{
+			{Name: "red", Size: 2},
+			{Name: "blue", Size: 2},
+		},
+		TeamRules: []models.TeamRule{
+			{Field: "mmr", Aggregate: models.AggregateAvg, MaxDiff: &[]float64{50}[0]},
+		},
+	}
+
+	now := time.Now()
+	players := []*models.MatchRequest{
+		{ID: "req1", PlayerID: "p1", Metadata: map[string]interface{}{"mmr": 1300}, CreatedAt: now.Add(-4 * time.Minute)},
+		{ID: "req2", PlayerID: "p2", Metadata: map[string]interface{}{"mmr": 1200}, CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "req3", PlayerID: "p3", Metadata: map[string]interface{}{"mmr": 1100}, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "req4", PlayerID: "p4", Metadata: map[string]interface{}{"mmr": 1000}, CreatedAt: now.Add(-time.Minute)},
+	}
+
+	matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+	// Filling teams in wait order would put 1300+1200 against 1100+1000; the only pairing
+	// within tolerance is 1300+1000 against 1200+1100
+	assert.Len(t, matches, 1)
+	teams := [][]string{matches[0].Teams["red"], matches[0].Teams["blue"]}
+	assert.ElementsMatch(t, [][]string{{"p1", "p4"}, {"p2", "p3"}}, [][]string{sortedIDs(teams[0]), sortedIDs(teams[1])})
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_TeamRulesUnsatisfiable(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-2v2",
+		Teams: []models.Team{
+			{Name: "red", Size: 2},
+			{Name: "blue", Size: 2},
+		},
+		TeamRules: []models.TeamRule{
+			{Field: "level", Aggregate: models.AggregateSum, Max: &[]float64{50}[0]},
+		},
+	}
+
+	var players []*models.MatchRequest
+	for i := 0; i < 4; i++ {
+		players = append(players, &models.MatchRequest{
+			ID:        fmt.Sprintf("req%d", i),
+			PlayerID:  fmt.Sprintf("p%d", i),
+			Metadata:  map[string]interface{}{"level": 30},
+			CreatedAt: time.Now(),
+		})
+	}
+
+	// Every pair of players sums to 60, so no team can stay within 50
+	assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players, config))
+}
+
+func sortedIDs(ids []string) []string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_BalanceBy(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-2v2",
+		Teams: []models.Team{
+			{Name: "red", Size: 2},
+			{Name: "blue", Size: 2},
+		},
+		BalanceBy: "mmr",
+	}
+
+	now := time.Now()
+	players := []*models.MatchRequest{
+		{ID: "req1", PlayerID: "p1", Metadata: map[string]interface{}{"mmr": 2000}, CreatedAt: now.Add(-4 * time.Minute)},
+		{ID: "req2", PlayerID: "p2", Metadata: map[string]interface{}{"mmr": 1900}, CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "req3", PlayerID: "p3", Metadata: map[string]interface{}{"mmr": 1500}, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "req4", PlayerID: "p4", Metadata: map[string]interface{}{"mmr": 1400}, CreatedAt: now.Add(-time.Minute)},
+	}
+
+	matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+	assert.Len(t, matches, 1)
+	assert.ElementsMatch(t, []string{"p1", "p4"}, matches[0].Teams["red"])
+	assert.ElementsMatch(t, []string{"p2", "p3"}, matches[0].Teams["blue"])
+	assert.Equal(t, map[string]float64{"red": 3400, "blue": 3400}, matches[0].TeamTotals)
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_BalanceByNestedField(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-2v2",
+		Teams: []models.Team{
+			{Name: "red", Size: 2},
+			{Name: "blue", Size: 2},
+		},
+		BalanceBy: "stats.mmr",
+	}
+
+	now := time.Now()
+	newPlayer := func(id string, mmr int, waited time.Duration) *models.MatchRequest {
+		return &models.MatchRequest{ID: "req-" + id, PlayerID: id, Metadata: map[string]interface{}{"stats": map[string]interface{}{"mmr": mmr}}, CreatedAt: now.Add(-waited)}
+	}
+	players := []*models.MatchRequest{
+		newPlayer("p1", 2000, 4*time.Minute),
+		newPlayer("p2", 1900, 3*time.Minute),
+		newPlayer("p3", 1500, 2*time.Minute),
+		newPlayer("p4", 1400, time.Minute),
+	}
+
+	matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+	require.Len(t, matches, 1)
+	assert.ElementsMatch(t, []string{"p1", "p4"}, matches[0].Teams["red"])
+	assert.Equal(t, map[string]float64{"red": 3400, "blue": 3400}, matches[0].TeamTotals)
+}
+
+func TestMatchmaker_BalanceTeams(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	newPlayers := func(values ...float64) []*models.MatchRequest {
+		var players []*models.MatchRequest
+		for i, v := range values {
+			players = append(players, &models.MatchRequest{
+				ID:       fmt.Sprintf("req%d", i),
+				Metadata: map[string]interface{}{"mmr": v},
+			})
+		}
+		return players
+	}
+
+	tests := []struct {
+		name    string
+		values  []float64
+		teams   []models.Team
+		wantGap float64
+	}{
+		{
+			name:    "Uneven team sizes",
+			values:  []float64{3000, 1000, 1000, 900},
+			teams:   []models.Team{{Name: "Solo", Size: 1}, {Name: "Trio", Size: 3}},
+			wantGap: 100,
+		},
+		{
+			name:    "Three teams exhaustive",
+			values:  []float64{10, 20, 30, 40, 50, 60},
+			teams:   []models.Team{{Name: "a", Size: 2}, {Name: "b", Size: 2}, {Name: "c", Size: 2}},
+			wantGap: 0,
+		},
+		{
+			name:    "Greedy for large teams",
+			values:  []float64{1800, 1750, 1700, 1650, 1600, 1550, 1500, 1450, 1400, 1350, 1300, 1250},
+			teams:   []models.Team{{Name: "red", Size: 6}, {Name: "blue", Size: 6}},
+			wantGap: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teams := matchmaker.balanceTeams(newPlayers(tt.values...), tt.teams, "mmr")
+
+			totals := matchmaker.teamTotals(teams, tt.teams, "mmr")
+			var values []float64
+			for i, team := range tt.teams {
+				assert.Len(t, teams[i], team.Size)
+				values = append(values, totals[team.Name])
+			}
+			assert.Equal(t, tt.wantGap, matchmaker.totalsGap(values))
+		})
+	}
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_Parties(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	party := func(id string, createdAt time.Time, playerIDs ...string) *models.MatchRequest {
+		req := &models.MatchRequest{ID: id, PlayerID: playerIDs[0], CreatedAt: createdAt}
+		for _, playerID := range playerIDs[1:] {
+			req.Members = append(req.Members, models.PartyMember{PlayerID: playerID})
+		}
+		return req
+	}
+	now := time.Now()
+
+	t.Run("Party stays on one team", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-2v2",
+			Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+		}
+		players := []*models.MatchRequest{
+			party("req1", now.Add(-3*time.Minute), "solo1"),
+			party("req2", now.Add(-2*time.Minute), "leader", "friend"),
+			party("req3", now.Add(-time.Minute), "solo2"),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+		assert.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"solo1", "solo2"}, matches[0].Teams["red"])
+		assert.ElementsMatch(t, []string{"leader", "friend"}, matches[0].Teams["blue"])
+	})
+
+	t.Run("Party fills the team it fits", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-1v3",
+			Teams:  []models.Team{{Name: "Solo", Size: 1}, {Name: "Trio", Size: 3}},
+		}
+		players := []*models.MatchRequest{
+			party("req1", now.Add(-2*time.Minute), "a", "b", "c"),
+			party("req2", now.Add(-time.Minute), "solo"),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+		assert.Len(t, matches, 1)
+		assert.Equal(t, []string{"solo"}, matches[0].Teams["Solo"])
+		assert.Equal(t, []string{"a", "b", "c"}, matches[0].Teams["Trio"])
+	})
+
+	t.Run("Party that fits no team is never matched", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-2v2",
+			Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+		}
+		players := []*models.MatchRequest{
+			party("req1", now.Add(-2*time.Minute), "a", "b", "c"),
+			party("req2", now.Add(-time.Minute), "d"),
+		}
+
+		assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players, config))
+	})
+
+	t.Run("Parties that can't be packed wait", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-3v3",
+			Teams:  []models.Team{{Name: "red", Size: 3}, {Name: "blue", Size: 3}},
+		}
+		players := []*models.MatchRequest{
+			party("req1", now.Add(-4*time.Minute), "a", "b"),
+			party("req2", now.Add(-3*time.Minute), "c", "d"),
+			party("req3", now.Add(-2*time.Minute), "e", "f"),
+			party("req4", now.Add(-time.Minute), "g"),
+			party("req5", now, "h"),
+		}
+
+		// Three pairs can't be packed into two teams of three, so the third pair waits
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+		assert.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"a", "b", "c", "d", "g", "h"}, matchmaker.FlattenTeams(matches[0].Teams))
+	})
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_Roles(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	player := func(id string, createdAt time.Time, roles ...interface{}) *models.MatchRequest {
+		return &models.MatchRequest{
+			ID:        id,
+			PlayerID:  id,
+			Metadata:  map[string]interface{}{models.RolesField: roles},
+			CreatedAt: createdAt,
+		}
+	}
+	now := time.Now()
+
+	t.Run("Fills every role slot", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-2v2",
+			Teams: []models.Team{
+				{Name: "red", Size: 2, Roles: map[string]int{"tank": 1, "dps": 1}},
+				{Name: "blue", Size: 2, Roles: map[string]int{"tank": 1, "dps": 1}},
+			},
+		}
+		players := []*models.MatchRequest{
+			player("flex", now.Add(-4*time.Minute), "tank", "dps"),
+			player("tank", now.Add(-3*time.Minute), "tank"),
+			player("dps1", now.Add(-2*time.Minute), "dps"),
+			player("dps2", now.Add(-time.Minute), "dps"),
+			player("dps3", now, "dps"),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+		assert.Len(t, matches, 1)
+		assert.Equal(t, map[string]string{"flex": "tank", "tank": "tank", "dps1": "dps", "dps2": "dps"}, matches[0].Roles)
+		for _, team := range matches[0].Teams {
+			roles := []string{matches[0].Roles[team[0]], matches[0].Roles[team[1]]}
+			assert.ElementsMatch(t, []string{"tank", "dps"}, roles)
+		}
+	})
+
+	t.Run("Waits for a missing role", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-1v1",
+			Teams: []models.Team{
+				{Name: "red", Size: 1, Roles: map[string]int{"tank": 1}},
+				{Name: "blue", Size: 1, Roles: map[string]int{"healer": 1}},
+			},
+		}
+		players := []*models.MatchRequest{
+			player("tank1", now.Add(-2*time.Minute), "tank"),
+			player("tank2", now.Add(-time.Minute), "tank"),
+		}
+
+		assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players, config))
+	})
+
+	t.Run("Prefers players who declared fewer roles", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-duo",
+			Teams:  []models.Team{{Name: "duo", Size: 2, Roles: map[string]int{"tank": 1, "healer": 1}}},
+		}
+		players := []*models.MatchRequest{
+			player("tank", now.Add(-3*time.Minute), "tank"),
+			player("flex", now.Add(-2*time.Minute), "tank", "healer"),
+			player("healer", now.Add(-time.Minute), "healer"),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+		assert.Len(t, matches, 1)
+		assert.Equal(t, map[string]string{"tank": "tank", "healer": "healer"}, matches[0].Roles)
+	})
+
+	t.Run("Players without roles fill any slot", func(t *testing.T) {
+		config := &models.GameConfig{
+			GameID: "game-duo",
+			Teams:  []models.Team{{Name: "duo", Size: 2, Roles: map[string]int{"tank": 1, "healer": 1}}},
+		}
+		players := []*models.MatchRequest{
+			{ID: "any", PlayerID: "any", CreatedAt: now.Add(-2 * time.Minute)},
+			player("tank", now.Add(-time.Minute), "tank"),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+
+		assert.Len(t, matches, 1)
+		assert.Equal(t, map[string]string{"any": "healer", "tank": "tank"}, matches[0].Roles)
+	})
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_FillDeadline(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-5v5",
+		Teams: []models.Team{
+			{Name: "red", MinSize: 3, MaxSize: 5},
+			{Name: "blue", MinSize: 3, MaxSize: 5},
+		},
+		FillDeadline: 60,
+	}
+	players := func(count int, waited time.Duration) []*models.MatchRequest {
+		var requests []*models.MatchRequest
+		for i := 0; i < count; i++ {
+			id := fmt.Sprintf("p%d", i)
+			requests = append(requests, &models.MatchRequest{ID: id, PlayerID: id, CreatedAt: time.Now().Add(-waited)})
+		}
+		return requests
+	}
+
+	t.Run("Waits for full teams before the deadline", func(t *testing.T) {
+		assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players(9, 10*time.Second), config))
+	})
+
+	t.Run("Launches short after the deadline", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players(9, 2*time.Minute), config)
+
+		assert.Len(t, matches, 1)
+		assert.ElementsMatch(t, []int{5, 4}, []int{len(matches[0].Teams["red"]), len(matches[0].Teams["blue"])})
+	})
+
+	t.Run("Keeps teams within one player", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players(7, 2*time.Minute), config)
+
+		assert.Len(t, matches, 1)
+		assert.ElementsMatch(t, []int{4, 3}, []int{len(matches[0].Teams["red"]), len(matches[0].Teams["blue"])})
+	})
+
+	t.Run("Never goes below min size", func(t *testing.T) {
+		assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players(5, 2*time.Minute), config))
+	})
+
+	t.Run("Fills full teams when enough players wait", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players(10, 2*time.Minute), config)
+
+		assert.Len(t, matches, 1)
+		assert.Len(t, matches[0].Teams["red"], 5)
+		assert.Len(t, matches[0].Teams["blue"], 5)
+	})
+}
+
+func TestMatchmaker_ProcessBackfill(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	config := &models.GameConfig{
+		GameID: "game-2v2",
+		Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+		Rules:  []models.Rule{{Field: "mmr", MaxSpread: &[]float64{100}[0], Strict: true}},
+	}
+	match := &models.MultiTeamMatch{
+		ID:     "match1",
+		GameID: "game-2v2",
+		Teams:  map[string][]string{"red": {"p1"}, "blue": {"p2", "p3"}},
+		PlayerMetadata: map[string]map[string]interface{}{
+			"p1": {"mmr": 1500},
+			"p2": {"mmr": 1520},
+			"p3": {"mmr": 1480},
+		},
+	}
+	now := time.Now()
+	newPlayer := func(id string, mmr int, createdAt time.Time) *models.MatchRequest {
+		return &models.MatchRequest{ID: id, PlayerID: id, Metadata: map[string]interface{}{"mmr": mmr}, CreatedAt: createdAt}
+	}
+	players := []*models.MatchRequest{
+		newPlayer("far", 1000, now.Add(-3*time.Minute)),
+		newPlayer("near", 1510, now.Add(-time.Minute)),
+		newPlayer("close", 1450, now.Add(-2*time.Minute)),
+		models.NewBackfillRequest(match, "blue", 1, nil),
+	}
+
+	t.Run("Takes the players closest to the match", func(t *testing.T) {
+		backfill := models.NewBackfillRequest(match, "red", 1, nil)
+		selected := matchmaker.ProcessBackfill(backfill, match, players, config)
+
+		assert.Equal(t, []string{"near"}, sortedIDs(matchmaker.getPlayerIDs(selected)))
+	})
+
+	emptied := &models.MultiTeamMatch{
+		ID:             match.ID,
+		GameID:         match.GameID,
+		Teams:          map[string][]string{"red": {}, "blue": {"p2", "p3"}},
+		PlayerMetadata: match.PlayerMetadata,
+	}
+
+	t.Run("Keeps the match within spread", func(t *testing.T) {
+		backfill := models.NewBackfillRequest(emptied, "red", 2, nil)
+		selected := matchmaker.ProcessBackfill(backfill, emptied, players, config)
+
+		assert.Equal(t, []string{"close", "near"}, sortedIDs(matchmaker.getPlayerIDs(selected)))
+	})
+
+	t.Run("Fills only the team's open seats", func(t *testing.T) {
+		backfill := models.NewBackfillRequest(match, "red", 2, nil)
+
+		assert.Nil(t, matchmaker.ProcessBackfill(backfill, match, players, config))
+	})
+
+	t.Run("Waits until every slot can be filled", func(t *testing.T) {
+		backfill := models.NewBackfillRequest(emptied, "red", 2, nil)
+
+		assert.Nil(t, matchmaker.ProcessBackfill(backfill, emptied, players[:2], config))
+	})
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_UnvalidatedRegex(t *testing.T) {
+	// A config stored by another replica reaches the matchmaker without this engine validating it
+	matchmaker := NewMatchmaker()
+	config := &models.GameConfig{
+		GameID: "game-1v1",
+		Teams:  []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+		Rules:  []models.Rule{{Field: "build", Regex: &[]string{`v2\.\d+`}[0], Strict: true}},
+	}
+	newPlayer := func(id, build string) *models.MatchRequest {
+		return &models.MatchRequest{ID: id, PlayerID: id, Metadata: map[string]interface{}{"build": build}, CreatedAt: time.Now()}
+	}
+	players := []*models.MatchRequest{newPlayer("a", "v2.1"), newPlayer("b", "v1.9"), newPlayer("c", "v2.14")}
+
+	matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+	require.Len(t, matches, 1)
+	assert.Equal(t, []string{"a", "c"}, sortedIDs(matchmaker.FlattenTeams(matches[0].Teams)))
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_Region(t *testing.T) {
+	matchmaker := NewMatchmaker()
+	now := time.Now()
+
+	newConfig := func(latency *models.LatencyRule) *models.GameConfig {
+		return &models.GameConfig{
+			GameID:  "game-1v1",
+			Teams:   []models.Team{{Name: "red", Size: 1}, {Name: "blue", Size: 1}},
+			Latency: latency,
+		}
+	}
+	newPlayer := func(id string, latencies map[string]int, waited time.Duration) *models.MatchRequest {
+		return &models.MatchRequest{ID: "req-" + id, PlayerID: id, Latencies: latencies, CreatedAt: now.Add(-waited)}
+	}
+
+	t.Run("Chooses the region with the lowest worst ping", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			newPlayer("a", map[string]int{"us-west": 20, "eu-west": 90, "ap-south": 10}, time.Minute),
+			newPlayer("b", map[string]int{"us-west": 80, "eu-west": 40}, time.Minute),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(nil))
+		require.Len(t, matches, 1)
+		assert.Equal(t, "us-west", matches[0].Region)
+	})
+
+	t.Run("Players without pings don't constrain the region", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			newPlayer("a", map[string]int{"us-west": 50, "eu-west": 30}, time.Minute),
+			newPlayer("b", nil, time.Minute),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(nil))
+		require.Len(t, matches, 1)
+		assert.Equal(t, "eu-west", matches[0].Region)
+	})
+
+	t.Run("Waits for a region within the max ping", func(t *testing.T) {
+		latency := &models.LatencyRule{MaxPing: 60, Relaxation: []models.LatencyStep{{After: 60, MaxPing: 100}}}
+		players := func(waited time.Duration) []*models.MatchRequest {
+			return []*models.MatchRequest{
+				newPlayer("a", map[string]int{"us-west": 20, "eu-west": 90}, waited),
+				newPlayer("b", map[string]int{"us-west": 80, "eu-west": 40}, waited),
+			}
+		}
+
+		assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players(10*time.Second), newConfig(latency)))
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players(2*time.Minute), newConfig(latency))
+		require.Len(t, matches, 1)
+		assert.Equal(t, "us-west", matches[0].Region)
+	})
+
+	t.Run("Matches players who share a region within the max ping", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			newPlayer("eu", map[string]int{"eu-west": 30}, 3*time.Minute),
+			newPlayer("us1", map[string]int{"us-west": 20, "eu-west": 150}, 2*time.Minute),
+			newPlayer("us2", map[string]int{"us-west": 40}, time.Minute),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(&models.LatencyRule{MaxPing: 100}))
+		require.Len(t, matches, 1)
+		assert.Equal(t, []string{"us1", "us2"}, sortedIDs(matchmaker.FlattenTeams(matches[0].Teams)))
+		assert.Equal(t, "us-west", matches[0].Region)
+	})
+
+	t.Run("Backfills only players within the max ping of the match's region", func(t *testing.T) {
+		config := newConfig(&models.LatencyRule{MaxPing: 100})
+		match := &models.MultiTeamMatch{
+			ID:     "match1",
+			GameID: "game-1v1",
+			Teams:  map[string][]string{"red": {"a"}},
+			Region: "us-west",
+		}
+		players := []*models.MatchRequest{
+			newPlayer("far", map[string]int{"us-west": 180, "eu-west": 20}, 2*time.Minute),
+			newPlayer("near", map[string]int{"us-west": 60}, time.Minute),
+		}
+
+		selected := matchmaker.ProcessBackfill(models.NewBackfillRequest(match, "blue", 1, nil), match, players, config)
+		assert.Equal(t, []string{"near"}, matchmaker.getPlayerIDs(selected))
+	})
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_SpreadWindow(t *testing.T) {
+	matchmaker := NewMatchmaker()
+	config := &models.GameConfig{
+		GameID: "test-game",
+		Teams: []models.Team{
+			{Name: "red", Size: 1},
+			{Name: "blue", Size: 1},
+		},
+		Rules: []models.Rule{
+			{
+				Field:      "skill",
+				MaxSpread:  &[]float64{100}[0],
+				Relaxation: []models.RelaxationStep{{After: 60, MaxSpread: &[]float64{500}[0]}},
+			},
+		},
+	}
+	now := time.Now()
+	ticket := func(id string, skill interface{}, waited time.Duration) *models.MatchRequest {
+		metadata := map[string]interface{}{}
+		if skill != nil {
+			metadata["skill"] = skill
+		}
+		return &models.MatchRequest{ID: id, PlayerID: id, Metadata: metadata, CreatedAt: now.Add(-waited)}
+	}
+
+	t.Run("Takes tickets without the field", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			ticket("anchor", 1000, 10*time.Second),
+			ticket("far", 1400, 5*time.Second),
+			ticket("unrated", nil, time.Second),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"anchor", "unrated"}, matchmaker.FlattenTeams(matches[0].Teams))
+	})
+
+	t.Run("Widens with relaxation", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			ticket("anchor", 1000, 90*time.Second),
+			ticket("far", 1400, 5*time.Second),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"anchor", "far"}, matchmaker.FlattenTeams(matches[0].Teams))
+	})
+}
+
+func BenchmarkProcessFullTeamMatchPool_DefaultStrategy(b *testing.B) {
+	matchmaker := NewMatchmaker()
+	matchmaker.logOutput = io.Discard // Silence the per-match logging
+	for _, teamSize := range []int{1, 5} {
+		for _, poolSize := range []int{200, 5000} {
+			rng := rand.New(rand.NewSource(1))
+			now := time.Now()
+			players := make([]*models.MatchRequest, poolSize)
+			for i := range players {
+				players[i] = &models.MatchRequest{
+					ID:        fmt.Sprintf("req%d", i),
+					PlayerID:  fmt.Sprintf("p%d", i),
+					Metadata:  map[string]interface{}{"skill": 1000 + rng.Intn(1000)},
+					CreatedAt: now.Add(-time.Duration(rng.Intn(600)) * time.Second),
+				}
+			}
+			config := &models.GameConfig{
+				GameID: "bench",
+				Teams: []models.Team{
+					{Name: "red", Size: teamSize},
+					{Name: "blue", Size: teamSize},
+				},
+				Rules: []models.Rule{
+					{Field: "skill", MaxSpread: &[]float64{100}[0], Strict: true},
+				},
+			}
+
+			b.Run(fmt.Sprintf("%dv%d/players=%d", teamSize, teamSize, poolSize), func(b *testing.B) {
+				var matched int
+				for i := 0; i < b.N; i++ {
+					matched = 0
+					for _, match := range matchmaker.ProcessFullTeamMatchPool(players, config) {
+						matched += len(matchmaker.FlattenTeams(match.Teams))
+					}
+				}
+				b.ReportMetric(float64(matched)/float64(poolSize), "matched/player")
+			})
+		}
+	}
+}
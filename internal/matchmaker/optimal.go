@@ -0,0 +1,497 @@
+package matchmaker
+
+import (
+	"maps"
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+)
+
+const (
+	// exactPairingLimit is the most units pairUnits pairs exactly; larger pools are paired greedily,
+	// most valuable pair first, then improved by re-pairing the units of two pairs at a time
+	exactPairingLimit = 18
+	// maxImprovePasses bounds the passes improvePairing makes over a greedy pairing
+	maxImprovePasses = 10
+	// pairingWindow is how many of its neighbours on the first max_spread rule's field each unit of a
+	// pool above exactPairingLimit may pair with
+	pairingWindow = 32
+
+	unmatchedPlayerCost = 10.0 // cost of leaving a player unmatched, far above any pair's cost so the most matches form
+	waitCredit          = 0.1  // extra cost per minute waited of leaving a player unmatched
+	regionMismatchCost  = 1.0  // cost of pairing players from different regions, as much as a full max_spread gap
+)
+
+// optimalStrategy pairs the whole queue at once for modes of two identical teams of one or two players,
+// solving for the pairing with the least total cost instead of matching one anchor at a time. A pair
+// costs its distance on the max_spread rules plus a penalty for players in different regions, and every
+// player left unmatched costs more the longer they have waited. In 2v2, solo players are first paired
+// into teams, then teams into matches. Other modes, and tickets left over, are matched like
+// skillClusteredStrategy.
+type optimalStrategy struct {
+	skillClusteredStrategy
+}
+
+func (s *optimalStrategy) Batch(players []*models.MatchRequest, config *models.GameConfig) [][]*models.MatchRequest {
+	m := s.m
+	teamSize, ok := m.pairingTeamSize(config.Teams)
+	if !ok {
+		return nil
+	}
+	layout := m.teamLayout(config.Teams, nil)
+
+	// A team is a ticket that fills it, or two solo tickets paired up
+	var teams, solos [][]*models.MatchRequest
+	for _, p := range players {
+		switch {
+		case p.Backfill != nil || p.Size() > teamSize:
+			continue
+		case p.Size() == teamSize:
+			teams = append(teams, []*models.MatchRequest{p})
+		default:
+			solos = append(solos, []*models.MatchRequest{p})
+		}
+	}
+	teams = append(teams, m.pairUnits(solos, config, func(team []*models.MatchRequest) bool {
+		return m.rolesFit(team, layout[0])
+	})...)
+
+	return m.pairUnits(teams, config, func(group []*models.MatchRequest) bool {
+		return m.assignTeams(group, config, s, layout, m.waitTime(group)) != nil
+	})
+}
+
+// pairingTeamSize returns the size of the teams if the config is a mode optimalStrategy pairs: two
+// teams with the same size of one or two players and the same roles
+func (m *Matchmaker) pairingTeamSize(teamConfigs []models.Team) (int, bool) {
+	if len(teamConfigs) != 2 {
+		return 0, false
+	}
+	a, b := teamConfigs[0], teamConfigs[1]
+	if a.MaxPlayers() != b.MaxPlayers() || a.MaxPlayers() > 2 || !maps.Equal(a.Roles, b.Roles) {
+		return 0, false
+	}
+	return a.MaxPlayers(), true
+}
+
+// pairUnits pairs up units, each a group of tickets, for the least total cost and returns the tickets
+// of each pair. Two units may only pair if every ticket passes the rules, the group passes the
+// max_spread rules and latency limit and valid accepts it, all evaluated with the group's longest wait.
+// Pools above exactPairingLimit only consider pairing each unit with the pairingWindow units after it
+// in order of the first max_spread rule's field, so the work grows linearly with the pool.
+func (m *Matchmaker) pairUnits(units [][]*models.MatchRequest, config *models.GameConfig, valid func([]*models.MatchRequest) bool) [][]*models.MatchRequest {
+	n := len(units)
+	pool := &pairingPool{
+		units:      units,
+		config:     config,
+		valid:      valid,
+		waits:      make([]time.Duration, n),
+		thresholds: m.relaxThresholds(config.Rules),
+		passes:     make(map[[2]int]bool),
+	}
+	unmatched := make([]float64, n)
+	for i, unit := range units {
+		unmatched[i] = m.unmatchedCost(unit)
+		pool.waits[i] = m.waitTime(unit)
+	}
+
+	cost := make(pairCosts, n)
+	var partner []int
+	if n <= exactPairingLimit {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				cost.add(i, j, m.pairCost(pool, i, j))
+			}
+		}
+		partner = m.pairExact(cost, unmatched)
+	} else {
+		order := m.pairingOrder(units, config.Rules)
+		for x, i := range order {
+			for _, j := range order[x+1 : min(x+1+pairingWindow, n)] {
+				cost.add(i, j, m.pairCost(pool, i, j))
+			}
+		}
+		cost.sort()
+		partner = m.pairGreedy(cost, unmatched)
+		m.improvePairing(partner, cost, unmatched)
+	}
+
+	var pairs [][]*models.MatchRequest
+	for i, j := range partner {
+		if j > i {
+			pair := append(append([]*models.MatchRequest{}, units[i]...), units[j]...)
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
+}
+
+// pairingPool holds the units pairUnits pairs and what it has worked out about them
+type pairingPool struct {
+	units      [][]*models.MatchRequest
+	config     *models.GameConfig
+	valid      func([]*models.MatchRequest) bool
+	waits      []time.Duration // each unit's longest wait
+	thresholds []float64       // waits, in seconds, at which a rule relaxes, ascending
+	passes     map[[2]int]bool // whether a unit's tickets pass the rules, by unit and thresholds reached
+}
+
+// pairEdge is a unit a unit may pair with and the cost of pairing them
+type pairEdge struct {
+	unit int
+	cost float64
+}
+
+// pairCosts holds, for each unit, the units it may pair with in ascending order and what each pair
+// costs. A pair without an edge can't be made.
+type pairCosts [][]pairEdge
+
+// add records the cost of pairing units i and j, unless they can't be paired
+func (c pairCosts) add(i, j int, cost float64) {
+	if math.IsInf(cost, 1) {
+		return
+	}
+	c[i] = append(c[i], pairEdge{j, cost})
+	c[j] = append(c[j], pairEdge{i, cost})
+}
+
+// sort puts each unit's edges in ascending order of unit
+func (c pairCosts) sort() {
+	for _, edges := range c {
+		sort.Slice(edges, func(a, b int) bool { return edges[a].unit < edges[b].unit })
+	}
+}
+
+// cost returns the cost of pairing units i and j, or +Inf if they can't be paired
+func (c pairCosts) cost(i, j int) float64 {
+	for _, e := range c[i] {
+		if e.unit == j {
+			return e.cost
+		}
+	}
+	return math.Inf(1)
+}
+
+// pairingOrder returns the indices of units sorted by their players' average of the first max_spread
+// rule's field, or by wait if there is no max_spread rule
+func (m *Matchmaker) pairingOrder(units [][]*models.MatchRequest, rules []models.Rule) []int {
+	field := m.spreadField(rules)
+	keys := make([]float64, len(units))
+	order := make([]int, len(units))
+	for i, unit := range units {
+		order[i] = i
+		if field == "" {
+			keys[i] = -m.waitTime(unit).Seconds()
+			continue
+		}
+		for _, ticket := range unit {
+			keys[i] += m.ticketTotal(ticket, field)
+		}
+		keys[i] /= float64(m.countPlayers(unit))
+	}
+	sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] < keys[order[b]] })
+	return order
+}
+
+// spreadField returns the field of the first max_spread rule, including those nested in all groups
+func (m *Matchmaker) spreadField(rules []models.Rule) string {
+	for _, rule := range rules {
+		if rule.All != nil {
+			if field := m.spreadField(rule.All); field != "" {
+				return field
+			}
+		} else if rule.MaxSpread != nil {
+			return rule.Field
+		}
+	}
+	return ""
+}
+
+// relaxThresholds returns the waits, in seconds, at which any of rules or their nested rules relaxes,
+// ascending. Whether a ticket passes the rules can only change when its wait crosses one of them.
+func (m *Matchmaker) relaxThresholds(rules []models.Rule) []float64 {
+	var thresholds []float64
+	var collect func(rules []models.Rule)
+	collect = func(rules []models.Rule) {
+		for _, rule := range rules {
+			if rule.RelaxAfter != nil {
+				thresholds = append(thresholds, float64(*rule.RelaxAfter))
+			}
+			for _, step := range rule.Relaxation {
+				thresholds = append(thresholds, float64(step.After))
+			}
+			collect(rule.All)
+			collect(rule.Any)
+			if rule.Not != nil {
+				collect([]models.Rule{*rule.Not})
+			}
+		}
+	}
+	collect(rules)
+	sort.Float64s(thresholds)
+	return thresholds
+}
+
+// unmatchedCost returns the cost of leaving a unit's players unmatched this pass
+func (m *Matchmaker) unmatchedCost(unit []*models.MatchRequest) float64 {
+	var cost float64
+	for _, ticket := range unit {
+		cost += float64(ticket.Size()) * (unmatchedPlayerCost + waitCredit*time.Since(ticket.CreatedAt).Minutes())
+	}
+	return cost
+}
+
+// unitPasses reports whether every ticket in unit i passes the rules after elapsed. Each unit is
+// evaluated at most once per relaxation threshold reached.
+func (m *Matchmaker) unitPasses(pool *pairingPool, i int, elapsed time.Duration) bool {
+	reached := sort.Search(len(pool.thresholds), func(k int) bool { return pool.thresholds[k] > elapsed.Seconds() })
+	key := [2]int{i, reached}
+	if passed, ok := pool.passes[key]; ok {
+		return passed
+	}
+
+	passed := true
+	for _, ticket := range pool.units[i] {
+		if ok, _ := m.ruleEngine.EvaluatePlayer(ticket, pool.config.Rules, elapsed); !ok {
+			passed = false
+			break
+		}
+	}
+	pool.passes[key] = passed
+	return passed
+}
+
+// pairCost returns the cost of pairing units i and j: their distance on the max_spread rules plus the
+// share of player pairs across them in different regions. It returns +Inf if they can't be paired,
+// including when no region is within the max ping for all of them.
+func (m *Matchmaker) pairCost(pool *pairingPool, i, j int) float64 {
+	a, b := pool.units[i], pool.units[j]
+	config := pool.config
+	elapsed := max(pool.waits[i], pool.waits[j])
+	if !m.unitPasses(pool, i, elapsed) || !m.unitPasses(pool, j, elapsed) {
+		return math.Inf(1)
+	}
+
+	group := append(append([]*models.MatchRequest{}, a...), b...)
+	if ok, _ := m.ruleEngine.EvaluateGroup(group, config.Rules, elapsed); !ok {
+		return math.Inf(1)
+	}
+	if _, ok := m.selectRegion(group, config, elapsed); !ok || !pool.valid(group) {
+		return math.Inf(1)
+	}
+	return m.ruleEngine.Distance(m.mergeTickets(a), m.mergeTickets(b), config.Rules, elapsed) + regionMismatchCost*m.regionMismatch(a, b)
+}
+
+// mergeTickets returns a single party ticket holding every player on tickets
+func (m *Matchmaker) mergeTickets(tickets []*models.MatchRequest) *models.MatchRequest {
+	if len(tickets) == 1 {
+		return tickets[0]
+	}
+	merged := &models.MatchRequest{ID: tickets[0].ID, PlayerID: tickets[0].PlayerID, Metadata: tickets[0].Metadata}
+	for _, ticket := range tickets {
+		for _, member := range ticket.MemberRequests() {
+			if member.PlayerID != merged.PlayerID {
+				merged.Members = append(merged.Members, models.PartyMember{PlayerID: member.PlayerID, Metadata: member.Metadata})
+			}
+		}
+	}
+	return merged
+}
+
+// regionMismatch returns the share of player pairs, one from each unit, whose regions are both set and
+// differ
+func (m *Matchmaker) regionMismatch(a, b []*models.MatchRequest) float64 {
+	var pairs, mismatched int
+	for _, ta := range a {
+		for _, pa := range ta.MemberRequests() {
+			for _, tb := range b {
+				for _, pb := range tb.MemberRequests() {
+					pairs++
+					if pa.Region() != "" && pb.Region() != "" && pa.Region() != pb.Region() {
+						mismatched++
+					}
+				}
+			}
+		}
+	}
+	return float64(mismatched) / float64(pairs)
+}
+
+// pairExact returns each unit's partner, or -1 if unmatched, in the pairing with the least total cost.
+// It solves over every subset of units, so it is only used for small pools.
+func (m *Matchmaker) pairExact(cost pairCosts, unmatched []float64) []int {
+	n := len(unmatched)
+	full := 1<<n - 1
+	best := make([]float64, full+1) // least cost of settling the units in the subset
+	choice := make([]int8, full+1)  // partner of the subset's lowest unit, or -1
+	for mask := 1; mask <= full; mask++ {
+		i := bits.TrailingZeros(uint(mask))
+		rest := mask &^ (1 << i)
+		best[mask], choice[mask] = unmatched[i]+best[rest], -1
+		for _, e := range cost[i] {
+			if rest&(1<<e.unit) == 0 {
+				continue
+			}
+			if c := e.cost + best[rest&^(1<<e.unit)]; c < best[mask] {
+				best[mask], choice[mask] = c, int8(e.unit)
+			}
+		}
+	}
+
+	partner := make([]int, n)
+	for mask := full; mask != 0; {
+		i := bits.TrailingZeros(uint(mask))
+		mask &^= 1 << i
+		partner[i] = int(choice[mask|1<<i])
+		if j := partner[i]; j >= 0 {
+			partner[j] = i
+			mask &^= 1 << j
+		}
+	}
+	return partner
+}
+
+// pairGreedy returns each unit's partner, or -1 if unmatched, taking pairs in order of how much they
+// save over leaving both units unmatched
+func (m *Matchmaker) pairGreedy(cost pairCosts, unmatched []float64) []int {
+	type edge struct {
+		i, j   int
+		saving float64
+	}
+	var edges []edge
+	for i := range cost {
+		for _, e := range cost[i] {
+			if saving := unmatched[i] + unmatched[e.unit] - e.cost; e.unit > i && saving > 0 {
+				edges = append(edges, edge{i, e.unit, saving})
+			}
+		}
+	}
+	sort.Slice(edges, func(a, b int) bool {
+		if edges[a].saving != edges[b].saving {
+			return edges[a].saving > edges[b].saving
+		}
+		return edges[a].i < edges[b].i || edges[a].i == edges[b].i && edges[a].j < edges[b].j
+	})
+
+	partner := make([]int, len(unmatched))
+	for i := range partner {
+		partner[i] = -1
+	}
+	for _, e := range edges {
+		if partner[e.i] < 0 && partner[e.j] < 0 {
+			partner[e.i], partner[e.j] = e.j, e.i
+		}
+	}
+	return partner
+}
+
+// improvePairing repeatedly re-pairs up to four units among themselves in the cheapest way: those of
+// two slots, each a pair or an unmatched unit, joined by a pair that could be made, and those of a pair
+// and two unmatched units each of its units could pair with. It stops once no change lowers the total
+// cost.
+func (m *Matchmaker) improvePairing(partner []int, cost pairCosts, unmatched []float64) {
+	// repair re-pairs units, which must hold the partners of all of them, if that lowers their cost
+	repair := func(units []int) bool {
+		var current float64
+		for _, u := range units {
+			if partner[u] < 0 {
+				current += unmatched[u]
+			} else if u < partner[u] {
+				current += cost.cost(u, partner[u])
+			}
+		}
+		total, edges, count := m.cheapestPairing(units, cost, unmatched)
+		if total >= current-1e-9 {
+			return false
+		}
+		for _, u := range units {
+			partner[u] = -1
+		}
+		for _, e := range edges[:count] {
+			partner[e[0]], partner[e[1]] = e[1], e[0]
+		}
+		return true
+	}
+
+	for pass, improved := 0, true; improved && pass < maxImprovePasses; pass++ {
+		improved = false
+		for a := range partner {
+			for _, e := range cost[a] {
+				c := e.unit
+				if c < a || partner[a] == c {
+					continue
+				}
+				units := []int{a, c}
+				for _, u := range []int{partner[a], partner[c]} {
+					if u >= 0 {
+						units = append(units, u)
+					}
+				}
+				if repair(units) {
+					improved = true
+				}
+			}
+		}
+
+		for a, b := range partner {
+			if b <= a {
+				continue // Each pair is visited from its lowest unit
+			}
+			for _, ec := range cost[a] {
+				for _, ed := range cost[b] {
+					c, d := ec.unit, ed.unit
+					if partner[a] != b || partner[c] >= 0 || partner[d] >= 0 || c == d {
+						continue // Re-paired earlier in this pass
+					}
+					if repair([]int{a, b, c, d}) {
+						improved = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// cheapestPairing returns the least cost of pairing up to four units among themselves, with the pairs
+// it takes
+func (m *Matchmaker) cheapestPairing(units []int, cost pairCosts, unmatched []float64) (float64, [2][2]int, int) {
+	var edges [6][2]int
+	var costs [6]float64
+	count := 0
+	for x := range units {
+		for y := x + 1; y < len(units); y++ {
+			if c := cost.cost(units[x], units[y]); !math.IsInf(c, 1) {
+				edges[count], costs[count] = [2]int{units[x], units[y]}, c
+				count++
+			}
+		}
+	}
+
+	base := 0.0
+	for _, u := range units {
+		base += unmatched[u]
+	}
+	saving := func(x int) float64 {
+		return unmatched[edges[x][0]] + unmatched[edges[x][1]] - costs[x]
+	}
+
+	var best [2][2]int
+	bestCount, bestTotal := 0, base
+	for x := 0; x < count; x++ {
+		if total := base - saving(x); total < bestTotal {
+			best, bestCount, bestTotal = [2][2]int{edges[x]}, 1, total
+		}
+		for y := x + 1; y < count; y++ {
+			if edges[x][0] == edges[y][0] || edges[x][0] == edges[y][1] || edges[x][1] == edges[y][0] || edges[x][1] == edges[y][1] {
+				continue
+			}
+			if total := base - saving(x) - saving(y); total < bestTotal {
+				best, bestCount, bestTotal = [2][2]int{edges[x], edges[y]}, 2, total
+			}
+		}
+	}
+	return bestTotal, best, bestCount
+}
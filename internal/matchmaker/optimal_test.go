@@ -0,0 +1,271 @@
+package matchmaker
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchmaker_ProcessFullTeamMatchPool_Optimal(t *testing.T) {
+	matchmaker := NewMatchmaker()
+	now := time.Now()
+
+	newConfig := func(size int, strategy string) *models.GameConfig {
+		return &models.GameConfig{
+			GameID: "game",
+			Teams: []models.Team{
+				{Name: "red", Size: size},
+				{Name: "blue", Size: size},
+			},
+			Rules: []models.Rule{
+				{Field: "skill", MaxSpread: &[]float64{100}[0], Strict: true},
+			},
+			Strategy: strategy,
+		}
+	}
+	newPlayer := func(id string, skill int, waited time.Duration) *models.MatchRequest {
+		return &models.MatchRequest{ID: "req-" + id, PlayerID: id, Metadata: map[string]interface{}{"skill": skill}, CreatedAt: now.Add(-waited)}
+	}
+
+	t.Run("1v1 pairs the whole queue", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			newPlayer("a", 1500, 4*time.Minute),
+			newPlayer("b", 1540, 3*time.Minute),
+			newPlayer("c", 1600, 2*time.Minute),
+			newPlayer("d", 1430, time.Minute),
+		}
+
+		// The anchor takes its closest player and strands the other two
+		assert.Len(t, matchmaker.ProcessFullTeamMatchPool(players, newConfig(1, StrategySkillClustered)), 1)
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(1, StrategyOptimal))
+		require.Len(t, matches, 2)
+		var pairs [][]string
+		for _, match := range matches {
+			pairs = append(pairs, sortedIDs(matchmaker.FlattenTeams(match.Teams)))
+		}
+		assert.ElementsMatch(t, [][]string{{"a", "d"}, {"b", "c"}}, pairs)
+	})
+
+	t.Run("1v1 keeps regions together", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			newPlayer("a", 1500, 4*time.Minute),
+			newPlayer("b", 1500, 3*time.Minute),
+			newPlayer("c", 1500, 2*time.Minute),
+			newPlayer("d", 1500, time.Minute),
+		}
+		players[0].Metadata["region"] = "us-west"
+		players[1].Metadata["region"] = "eu-west"
+		players[2].Metadata["region"] = "eu-west"
+		players[3].Metadata["region"] = "us-west"
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(1, StrategyOptimal))
+		require.Len(t, matches, 2)
+		for _, match := range matches {
+			ids := sortedIDs(matchmaker.FlattenTeams(match.Teams))
+			assert.Contains(t, [][]string{{"a", "d"}, {"b", "c"}}, ids)
+		}
+	})
+
+	t.Run("1v1 matches the longest waiting when one must be left out", func(t *testing.T) {
+		players := []*models.MatchRequest{
+			newPlayer("a", 1500, time.Minute),
+			newPlayer("b", 1500, 30*time.Minute),
+			newPlayer("c", 1500, 20*time.Minute),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(1, StrategyOptimal))
+		require.Len(t, matches, 1)
+		assert.Equal(t, []string{"b", "c"}, sortedIDs(matchmaker.FlattenTeams(matches[0].Teams)))
+	})
+
+	t.Run("2v2 pairs solos into teams and teams into matches", func(t *testing.T) {
+		party := newPlayer("p1", 1520, 5*time.Minute)
+		party.Members = []models.PartyMember{{PlayerID: "p2", Metadata: map[string]interface{}{"skill": 1480}}}
+		players := []*models.MatchRequest{
+			party,
+			newPlayer("s1", 1490, 4*time.Minute),
+			newPlayer("s2", 1700, 3*time.Minute),
+			newPlayer("s3", 1510, 2*time.Minute),
+			newPlayer("s4", 1650, time.Minute),
+			newPlayer("s5", 1690, time.Minute),
+			newPlayer("s6", 1660, time.Minute),
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(2, StrategyOptimal))
+		require.Len(t, matches, 2)
+		var groups [][]string
+		for _, match := range matches {
+			groups = append(groups, sortedIDs(matchmaker.FlattenTeams(match.Teams)))
+			for _, team := range match.Teams {
+				assert.Len(t, team, 2)
+			}
+		}
+		assert.ElementsMatch(t, [][]string{{"p1", "p2", "s1", "s3"}, {"s2", "s4", "s5", "s6"}}, groups)
+	})
+
+	t.Run("other modes fall back to the anchor loop", func(t *testing.T) {
+		config := newConfig(3, StrategyOptimal)
+		var players []*models.MatchRequest
+		for i := 0; i < 6; i++ {
+			players = append(players, newPlayer(fmt.Sprintf("p%d", i), 1500+i*10, time.Duration(i)*time.Minute))
+		}
+
+		matches := matchmaker.ProcessFullTeamMatchPool(players, config)
+		require.Len(t, matches, 1)
+		assert.Len(t, matchmaker.FlattenTeams(matches[0].Teams), 6)
+	})
+}
+
+func TestMatchmaker_PairUnits_LargePool(t *testing.T) {
+	matchmaker := NewMatchmaker()
+	config := &models.GameConfig{Rules: []models.Rule{{Field: "skill", MaxSpread: &[]float64{100}[0], Strict: true}}}
+	now := time.Now()
+
+	// Each group of four can only all be matched as a-d and b-c, while a-b is the cheapest pair
+	var units [][]*models.MatchRequest
+	for g := 0; g < 6; g++ {
+		base := g * 1000
+		for i, skill := range []int{1500, 1540, 1600, 1430} {
+			units = append(units, []*models.MatchRequest{{
+				ID:        fmt.Sprintf("req-%d-%d", g, i),
+				PlayerID:  fmt.Sprintf("p-%d-%d", g, i),
+				Metadata:  map[string]interface{}{"skill": base + skill},
+				CreatedAt: now.Add(-time.Duration(i) * time.Minute),
+			}})
+		}
+	}
+	require.Greater(t, len(units), exactPairingLimit)
+
+	pairs := matchmaker.pairUnits(units, config, func([]*models.MatchRequest) bool { return true })
+
+	assert.Len(t, pairs, 12)
+}
+
+func TestMatchmaker_PairUnits_RelaxesWithPartnerWait(t *testing.T) {
+	matchmaker := NewMatchmaker()
+	config := &models.GameConfig{Rules: []models.Rule{{Field: "level", Min: &[]float64{10}[0], Strict: true, RelaxAfter: &[]int{60}[0]}}}
+	now := time.Now()
+
+	// Every unit is below the min level, which only relaxes for pairs with a two-minute waiter
+	var units [][]*models.MatchRequest
+	for i := 0; i < 2*exactPairingLimit; i++ {
+		waited := 10 * time.Second
+		if i%2 == 0 {
+			waited = 2 * time.Minute
+		}
+		units = append(units, []*models.MatchRequest{{
+			ID:        fmt.Sprintf("req-%d", i),
+			PlayerID:  fmt.Sprintf("p-%d", i),
+			Metadata:  map[string]interface{}{"level": 5},
+			CreatedAt: now.Add(-waited),
+		}})
+	}
+
+	valid := func([]*models.MatchRequest) bool { return true }
+	for _, n := range []int{4, len(units)} {
+		pairs := matchmaker.pairUnits(units[:n], config, valid)
+		require.Len(t, pairs, n/2)
+		for _, pair := range pairs {
+			assert.True(t, pair[0].CreatedAt.Before(now.Add(-time.Minute)) || pair[1].CreatedAt.Before(now.Add(-time.Minute)))
+		}
+	}
+}
+
+func TestMatchmaker_PairGreedy_CloseToExact(t *testing.T) {
+	matchmaker := NewMatchmaker()
+	rng := rand.New(rand.NewSource(1))
+
+	total := func(partner []int, cost pairCosts, unmatched []float64) (float64, int) {
+		var sum float64
+		var pairs int
+		for i, j := range partner {
+			if j < 0 {
+				sum += unmatched[i]
+			} else if i < j {
+				sum += cost.cost(i, j)
+				pairs++
+			}
+		}
+		return sum, pairs
+	}
+
+	for trial := 0; trial < 50; trial++ {
+		n := 4 + rng.Intn(11)
+		unmatched := make([]float64, n)
+		cost := make(pairCosts, n)
+		for i := range unmatched {
+			unmatched[i] = unmatchedPlayerCost + rng.Float64()
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				c := rng.Float64() * 2
+				if rng.Intn(3) == 0 {
+					c = math.Inf(1)
+				}
+				cost.add(i, j, c)
+			}
+		}
+
+		exact, exactPairs := total(matchmaker.pairExact(cost, unmatched), cost, unmatched)
+		greedy := matchmaker.pairGreedy(cost, unmatched)
+		matchmaker.improvePairing(greedy, cost, unmatched)
+		approx, approxPairs := total(greedy, cost, unmatched)
+
+		assert.GreaterOrEqual(t, approx, exact-1e-9, "trial %d", trial)
+		assert.GreaterOrEqual(t, approxPairs, exactPairs-1, "trial %d", trial)
+	}
+}
+
+func BenchmarkProcessFullTeamMatchPool(b *testing.B) {
+	matchmaker := NewMatchmaker()
+	matchmaker.logOutput = io.Discard // Silence the per-match logging
+	for _, teamSize := range []int{1, 2} {
+		for _, poolSize := range []int{16, 200, 5000} {
+			rng := rand.New(rand.NewSource(1))
+			now := time.Now()
+			players := make([]*models.MatchRequest, poolSize)
+			for i := range players {
+				players[i] = &models.MatchRequest{
+					ID:        fmt.Sprintf("req%d", i),
+					PlayerID:  fmt.Sprintf("p%d", i),
+					Metadata:  map[string]interface{}{"skill": 1000 + rng.Intn(1000)},
+					CreatedAt: now.Add(-time.Duration(rng.Intn(600)) * time.Second),
+				}
+			}
+
+			for _, strategy := range []string{StrategySkillClustered, StrategyOptimal} {
+				config := &models.GameConfig{
+					GameID: "bench",
+					Teams: []models.Team{
+						{Name: "red", Size: teamSize},
+						{Name: "blue", Size: teamSize},
+					},
+					Rules: []models.Rule{
+						{Field: "skill", MaxSpread: &[]float64{100}[0], Strict: true},
+					},
+					Strategy: strategy,
+				}
+
+				name := fmt.Sprintf("%dv%d/players=%d/%s", teamSize, teamSize, poolSize, strategy)
+				b.Run(name, func(b *testing.B) {
+					var matched int
+					for i := 0; i < b.N; i++ {
+						matched = 0
+						for _, match := range matchmaker.ProcessFullTeamMatchPool(players, config) {
+							matched += len(matchmaker.FlattenTeams(match.Teams))
+						}
+					}
+					b.ReportMetric(float64(matched)/float64(poolSize), "matched/player")
+				})
+			}
+		}
+	}
+}
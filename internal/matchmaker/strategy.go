@@ -0,0 +1,140 @@
+package matchmaker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+)
+
+// Built-in strategy names
+const (
+	StrategyFIFO           = "fifo"            // oldest tickets first
+	StrategySkillClustered = "skill_clustered" // tickets closest to the anchor first
+	StrategyBalanced       = "balanced"        // closest tickets, always split into balanced teams
+	StrategyOptimal        = "optimal"         // least-cost pairing across the whole queue for 1v1 and 2v2
+
+	// DefaultStrategy is used by game configs that don't name a strategy
+	DefaultStrategy = StrategySkillClustered
+)
+
+// Strategy decides which tickets make up a match and how they are split into teams. The matchmaker
+// still picks the anchors and enforces the rules, parties, role slots, team rules and fill deadline.
+type Strategy interface {
+	// Rank orders the candidates for a match around anchor in place, best first
+	Rank(anchor *models.MatchRequest, candidates []*models.MatchRequest, config *models.GameConfig, elapsed time.Duration)
+	// Split assigns the selected tickets to the layout's teams, returning nil if they can't be split
+	Split(selected []*models.MatchRequest, layout []models.Team, config *models.GameConfig) [][]*models.MatchRequest
+}
+
+// BatchStrategy is a Strategy that also selects matches across the whole pool at once. The matchmaker
+// forms a match from each group Batch returns, then forms matches from the tickets left over one anchor
+// at a time with Rank and Split.
+type BatchStrategy interface {
+	Strategy
+	// Batch groups tickets from the pool into the tickets of whole matches
+	Batch(players []*models.MatchRequest, config *models.GameConfig) [][]*models.MatchRequest
+}
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[string]Strategy)
+)
+
+func init() {
+	m := NewMatchmaker()
+	RegisterStrategy(StrategyFIFO, &fifoStrategy{m})
+	RegisterStrategy(StrategySkillClustered, &skillClusteredStrategy{m})
+	RegisterStrategy(StrategyBalanced, &balancedStrategy{skillClusteredStrategy{m}})
+	RegisterStrategy(StrategyOptimal, &optimalStrategy{skillClusteredStrategy{m}})
+}
+
+// RegisterStrategy makes a strategy available to game configs under name. It panics if name is
+// empty or already registered, or if strategy is nil.
+func RegisterStrategy(name string, strategy Strategy) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if name == "" {
+		panic("matchmaker: RegisterStrategy name is empty")
+	}
+	if strategy == nil {
+		panic("matchmaker: RegisterStrategy strategy is nil")
+	}
+	if _, dup := strategies[name]; dup {
+		panic(fmt.Sprintf("matchmaker: RegisterStrategy called twice for %q", name))
+	}
+	strategies[name] = strategy
+}
+
+// LookupStrategy returns the strategy registered under name; an empty name selects DefaultStrategy
+func LookupStrategy(name string) (Strategy, bool) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	strategy, ok := strategies[name]
+	return strategy, ok
+}
+
+// Strategies returns the names of the registered strategies, sorted
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fifoStrategy fills matches with the longest-waiting compatible tickets, ignoring skill distance
+type fifoStrategy struct {
+	m *Matchmaker
+}
+
+func (s *fifoStrategy) Rank(anchor *models.MatchRequest, candidates []*models.MatchRequest, config *models.GameConfig, elapsed time.Duration) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CreatedAt.Before(candidates[j].CreatedAt)
+	})
+}
+
+func (s *fifoStrategy) Split(selected []*models.MatchRequest, layout []models.Team, config *models.GameConfig) [][]*models.MatchRequest {
+	return s.m.splitTeams(selected, layout, config.BalanceBy)
+}
+
+// skillClusteredStrategy fills matches with the tickets closest to the anchor on the max_spread rules,
+// preferring players who declared fewer roles, and balances teams only when balance_by is set
+type skillClusteredStrategy struct {
+	m *Matchmaker
+}
+
+func (s *skillClusteredStrategy) Rank(anchor *models.MatchRequest, candidates []*models.MatchRequest, config *models.GameConfig, elapsed time.Duration) {
+	s.m.sortByDistance(anchor, candidates, config.Rules, s.m.roleNames(config.Teams), elapsed)
+}
+
+func (s *skillClusteredStrategy) Split(selected []*models.MatchRequest, layout []models.Team, config *models.GameConfig) [][]*models.MatchRequest {
+	return s.m.splitTeams(selected, layout, config.BalanceBy)
+}
+
+// balancedStrategy selects tickets like skillClusteredStrategy but always balances teams, on balance_by
+// or, if unset, on the field of the first max_spread rule
+type balancedStrategy struct {
+	skillClusteredStrategy
+}
+
+func (s *balancedStrategy) Split(selected []*models.MatchRequest, layout []models.Team, config *models.GameConfig) [][]*models.MatchRequest {
+	field := config.BalanceBy
+	if field == "" {
+		for _, rule := range config.Rules {
+			if rule.MaxSpread != nil {
+				field = rule.Field
+				break
+			}
+		}
+	}
+	return s.m.splitTeams(selected, layout, field)
+}
@@ -0,0 +1,128 @@
+package matchmaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newestFirstStrategy reverses the candidate order and deals the selected tickets round robin across
+// the teams, or declines every match when told to
+type newestFirstStrategy struct {
+	decline bool
+}
+
+func (s *newestFirstStrategy) Rank(anchor *models.MatchRequest, candidates []*models.MatchRequest, config *models.GameConfig, elapsed time.Duration) {
+	for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+}
+
+func (s *newestFirstStrategy) Split(selected []*models.MatchRequest, layout []models.Team, config *models.GameConfig) [][]*models.MatchRequest {
+	if s.decline {
+		return nil
+	}
+	teams := make([][]*models.MatchRequest, len(layout))
+	for i, ticket := range selected {
+		teams[i%len(layout)] = append(teams[i%len(layout)], ticket)
+	}
+	return teams
+}
+
+func init() {
+	RegisterStrategy("test_newest_first", &newestFirstStrategy{})
+	RegisterStrategy("test_decline", &newestFirstStrategy{decline: true})
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	strategy, ok := LookupStrategy("test_newest_first")
+	assert.True(t, ok)
+	assert.IsType(t, &newestFirstStrategy{}, strategy)
+	assert.Subset(t, Strategies(), []string{StrategyBalanced, StrategyFIFO, StrategySkillClustered, "test_newest_first"})
+
+	assert.Panics(t, func() { RegisterStrategy("test_newest_first", &newestFirstStrategy{}) })
+	assert.Panics(t, func() { RegisterStrategy("", &newestFirstStrategy{}) })
+	assert.Panics(t, func() { RegisterStrategy("test_nil", nil) })
+}
+
+func TestLookupStrategy(t *testing.T) {
+	strategy, ok := LookupStrategy("")
+	require.True(t, ok)
+	assert.IsType(t, &skillClusteredStrategy{}, strategy)
+
+	_, ok = LookupStrategy("unknown")
+	assert.False(t, ok)
+}
+
+func TestMatchmaker_ProcessFullTeamMatchPool_Strategy(t *testing.T) {
+	matchmaker := NewMatchmaker()
+
+	now := time.Now()
+	players := []*models.MatchRequest{
+		{ID: "req1", PlayerID: "p1", Metadata: map[string]interface{}{"mmr": 2000}, CreatedAt: now.Add(-4 * time.Minute)},
+		{ID: "req2", PlayerID: "p2", Metadata: map[string]interface{}{"mmr": 1400}, CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "req3", PlayerID: "p3", Metadata: map[string]interface{}{"mmr": 1500}, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "req4", PlayerID: "p4", Metadata: map[string]interface{}{"mmr": 1900}, CreatedAt: now.Add(-time.Minute)},
+		{ID: "req5", PlayerID: "p5", Metadata: map[string]interface{}{"mmr": 1950}, CreatedAt: now},
+	}
+	newConfig := func(strategy string) *models.GameConfig {
+		return &models.GameConfig{
+			GameID: "game-2v2",
+			Teams: []models.Team{
+				{Name: "red", Size: 2},
+				{Name: "blue", Size: 2},
+			},
+			Rules: []models.Rule{
+				{Field: "mmr", MaxSpread: &[]float64{1000}[0], Strict: true},
+			},
+			Strategy: strategy,
+		}
+	}
+
+	t.Run("skill clustered", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(StrategySkillClustered))
+
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"p1", "p5"}, matches[0].Teams["red"])
+		assert.ElementsMatch(t, []string{"p4", "p3"}, matches[0].Teams["blue"])
+		assert.Equal(t, []string{"req1", "req3", "req4", "req5"}, matches[0].RequestIDs)
+	})
+
+	t.Run("fifo", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(StrategyFIFO))
+
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"p1", "p2"}, matches[0].Teams["red"])
+		assert.ElementsMatch(t, []string{"p3", "p4"}, matches[0].Teams["blue"])
+	})
+
+	t.Run("balanced on the max_spread field", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig(StrategyBalanced))
+
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"p1", "p3"}, matches[0].Teams["red"])
+		assert.ElementsMatch(t, []string{"p5", "p4"}, matches[0].Teams["blue"])
+	})
+
+	t.Run("registered strategy", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig("test_newest_first"))
+
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"p1", "p4"}, matches[0].Teams["red"])
+		assert.ElementsMatch(t, []string{"p5", "p3"}, matches[0].Teams["blue"])
+	})
+
+	t.Run("declined split", func(t *testing.T) {
+		assert.Empty(t, matchmaker.ProcessFullTeamMatchPool(players, newConfig("test_decline")))
+	})
+
+	t.Run("unknown strategy uses the default", func(t *testing.T) {
+		matches := matchmaker.ProcessFullTeamMatchPool(players, newConfig("unknown"))
+
+		require.Len(t, matches, 1)
+		assert.ElementsMatch(t, []string{"p1", "p5"}, matches[0].Teams["red"])
+	})
+}
@@ -14,42 +14,215 @@ type MatchRequest struct {
 	Metadata  map[string]interface{} `json:"metadata"`
 	CreatedAt time.Time              `json:"created_at"`
 	Status    MatchStatus            `json:"status"`
+	TTL       int                    `json:"ttl,omitempty"`       // seconds the ticket lives without a heartbeat
+	Members   []PartyMember          `json:"members,omitempty"`   // other players queueing on this ticket as a party
+	Backfill  *BackfillTarget        `json:"backfill,omitempty"`  // set on tickets requesting replacements for a running match
+	Latencies map[string]int         `json:"latencies,omitempty"` // region -> measured ping in ms
+}
+
+// BackfillTarget identifies the match and team a backfill ticket requests replacement players for
+type BackfillTarget struct {
+	MatchID  string   `json:"match_id"`
+	TeamName string   `json:"team_name"`
+	Slots    int      `json:"slots"`
+	Departed []string `json:"departed,omitempty"` // players leaving the team, removed from the match when it is filled
+}
+
+// PartyMember is a player queueing on another player's ticket
+type PartyMember struct {
+	PlayerID  string                 `json:"player_id"`
+	Metadata  map[string]interface{} `json:"metadata"`
+	Latencies map[string]int         `json:"latencies,omitempty"` // region -> measured ping in ms
+}
+
+// Size returns the number of players on the ticket
+func (r *MatchRequest) Size() int {
+	return 1 + len(r.Members)
+}
+
+// PlayerIDs returns the IDs of every player on the ticket, leader first
+func (r *MatchRequest) PlayerIDs() []string {
+	ids := make([]string, 0, r.Size())
+	ids = append(ids, r.PlayerID)
+	for _, member := range r.Members {
+		ids = append(ids, member.PlayerID)
+	}
+	return ids
+}
+
+// RolesField is the metadata field in which a player lists the roles they are willing to play
+const RolesField = "roles"
+
+// Roles returns the roles the ticket's player is willing to play, read from the roles metadata field
+// as either a list or a single string. A player who declares no roles can fill any role.
+func (r *MatchRequest) Roles() []string {
+	switch v := r.Metadata[RolesField].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		roles := make([]string, 0, len(v))
+		for _, role := range v {
+			if s, ok := role.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+	return nil
+}
+
+// RegionField is the metadata field holding the region a player wants to play in
+const RegionField = "region"
+
+// Region returns the ticket's player's region from the region metadata field, or "" if unset
+func (r *MatchRequest) Region() string {
+	region, _ := r.Metadata[RegionField].(string)
+	return region
+}
+
+// MemberRequests returns one request per player on the ticket, leader first, each carrying that
+// player's metadata. A solo ticket returns just itself.
+func (r *MatchRequest) MemberRequests() []*MatchRequest {
+	requests := make([]*MatchRequest, 0, r.Size())
+	requests = append(requests, r)
+	for _, member := range r.Members {
+		requests = append(requests, &MatchRequest{
+			ID:        r.ID,
+			PlayerID:  member.PlayerID,
+			GameID:    r.GameID,
+			Metadata:  member.Metadata,
+			Latencies: member.Latencies,
+			CreatedAt: r.CreatedAt,
+			Status:    r.Status,
+			TTL:       r.TTL,
+		})
+	}
+	return requests
 }
 
 // MatchStatus represents the current status of a match request
 type MatchStatus string
 
 const (
-	StatusPending   MatchStatus = "pending"
-	StatusMatched   MatchStatus = "matched"
-	StatusAllocated MatchStatus = "allocated"
-	StatusFailed    MatchStatus = "failed"
+	StatusPending        MatchStatus = "pending"
+	StatusAwaitingAccept MatchStatus = "awaiting_accept"
+	StatusMatched        MatchStatus = "matched"
+	StatusAllocated      MatchStatus = "allocated"
+	StatusFailed         MatchStatus = "failed"
+	StatusCancelled      MatchStatus = "cancelled"
 )
 
 // GameConfig represents the rules and team configuration for a game
 type GameConfig struct {
-	GameID    string    `json:"game_id"`
-	Teams     []Team    `json:"teams"`
-	Rules     []Rule    `json:"rules"`
-	UpdatedAt time.Time `json:"updated_at"`
+	GameID       string       `json:"game_id"`
+	Teams        []Team       `json:"teams"`
+	Rules        []Rule       `json:"rules"`
+	TeamRules    []TeamRule   `json:"team_rules,omitempty"`
+	BalanceBy    string       `json:"balance_by,omitempty"`    // metadata field whose team totals are equalised
+	Strategy     string       `json:"strategy,omitempty"`      // matching strategy name; empty uses the default
+	TicketTTL    int          `json:"ticket_ttl,omitempty"`    // seconds; 0 uses the server default
+	FillDeadline int          `json:"fill_deadline,omitempty"` // seconds before a match may launch with short teams; 0 never
+	AcceptWindow int          `json:"accept_window,omitempty"` // seconds players have to accept a match; 0 skips the ready check
+	Latency      *LatencyRule `json:"latency,omitempty"`       // limit on players' ping in the region chosen for a match
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
 
 // Team represents a team configuration
 type Team struct {
-	Name string `json:"name"`
-	Size int    `json:"size"`
+	Name    string         `json:"name"`
+	Size    int            `json:"size"`
+	MinSize int            `json:"min_size,omitempty"` // fewest players the team launches with once the fill deadline passes
+	MaxSize int            `json:"max_size,omitempty"` // alternative to Size
+	Roles   map[string]int `json:"roles,omitempty"`    // role -> number of slots; counts must add up to the max size
+}
+
+// MaxPlayers returns the number of players in a full team: MaxSize if set, otherwise Size
+func (t Team) MaxPlayers() int {
+	if t.MaxSize > 0 {
+		return t.MaxSize
+	}
+	return t.Size
+}
+
+// MinPlayers returns the fewest players the team may launch with: MinSize if set, otherwise a full team
+func (t Team) MinPlayers() int {
+	if t.MinSize > 0 {
+		return t.MinSize
+	}
+	return t.MaxPlayers()
 }
 
 // Rule represents a matchmaking rule
 type Rule struct {
-	Field      string  `json:"field"`
-	Min        *int    `json:"min,omitempty"`
-	Max        *int    `json:"max,omitempty"`
-	Contains   *string `json:"contains,omitempty"`
-	Equals     *string `json:"equals,omitempty"`
-	Strict     bool    `json:"strict"`
-	RelaxAfter *int    `json:"relax_after,omitempty"` // seconds
-	Priority   int     `json:"priority"`              // higher = more important
+	Field      string           `json:"field"`
+	Min        *float64         `json:"min,omitempty"`
+	Max        *float64         `json:"max,omitempty"`
+	Contains   *string          `json:"contains,omitempty"`
+	Equals     *string          `json:"equals,omitempty"`
+	NotEquals  *string          `json:"not_equals,omitempty"`
+	In         []string         `json:"in,omitempty"`         // value must be one of these
+	NotIn      []string         `json:"not_in,omitempty"`     // value must be none of these
+	Regex      *string          `json:"regex,omitempty"`      // must match the whole value
+	Exists     *bool            `json:"exists,omitempty"`     // field must be present (true) or absent (false)
+	MaxSpread  *float64         `json:"max_spread,omitempty"` // max difference in the field's value across a match
+	Strict     bool             `json:"strict"`
+	RelaxAfter *int             `json:"relax_after,omitempty"` // seconds
+	Priority   int              `json:"priority"`              // higher = more important
+	Relaxation []RelaxationStep `json:"relaxation,omitempty"`  // progressively wider bounds, in order of After
+	// PartyAggregate evaluates the rule once against an aggregate (avg, sum, min or max) of a party's
+	// values instead of against every member
+	PartyAggregate string `json:"party_aggregate,omitempty"`
+	// All, Any and Not make the rule a group that passes when every, at least one or none of its rules
+	// pass. A group sets exactly one of them, and no field or criteria of its own.
+	All []Rule `json:"all,omitempty"`
+	Any []Rule `json:"any,omitempty"`
+	Not *Rule  `json:"not,omitempty"`
+}
+
+// IsGroup reports whether the rule combines other rules rather than checking a field
+func (r Rule) IsGroup() bool {
+	return r.All != nil || r.Any != nil || r.Not != nil
+}
+
+// LatencyRule limits the ping of the worst-placed player in the region chosen for a match
+type LatencyRule struct {
+	MaxPing    int           `json:"max_ping"`             // ms
+	Relaxation []LatencyStep `json:"relaxation,omitempty"` // progressively higher limits, in order of After
+}
+
+// LatencyStep raises a latency rule's max ping once a match's longest-waiting player has waited After seconds
+type LatencyStep struct {
+	After   int `json:"after"`    // seconds
+	MaxPing int `json:"max_ping"` // ms
+}
+
+// Team aggregates supported by TeamRule
+const (
+	AggregateAvg = "avg"
+	AggregateSum = "sum"
+	AggregateMin = "min"
+	AggregateMax = "max"
+)
+
+// TeamRule constrains an aggregate of a metadata field over the players of each team in a match
+type TeamRule struct {
+	Field      string   `json:"field"`
+	Aggregate  string   `json:"aggregate"`             // avg, sum, min or max
+	Min        *float64 `json:"min,omitempty"`         // lower bound on every team's aggregate
+	Max        *float64 `json:"max,omitempty"`         // upper bound on every team's aggregate
+	MaxDiff    *float64 `json:"max_diff,omitempty"`    // max difference between any two teams' aggregates
+	RelaxAfter *int     `json:"relax_after,omitempty"` // seconds
+}
+
+// RelaxationStep replaces a rule's bounds once a player has waited After seconds.
+// Only the bounds set on the step are replaced.
+type RelaxationStep struct {
+	After     int      `json:"after"` // seconds
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	MaxSpread *float64 `json:"max_spread,omitempty"`
 }
 
 // Match represents a successful match of players
@@ -60,6 +233,7 @@ type Match struct {
 	Players   []string     `json:"players"`
 	CreatedAt time.Time    `json:"created_at"`
 	Session   *GameSession `json:"session,omitempty"`
+	Region    string       `json:"region,omitempty"` // region to host the session in
 }
 
 // GameSession represents the allocated game session
@@ -72,24 +246,59 @@ type GameSession struct {
 // MultiTeamMatch represents a match with multiple teams
 // team name -> player IDs
 type MultiTeamMatch struct {
-	ID        string              `json:"id"`
-	GameID    string              `json:"game_id"`
-	Teams     map[string][]string `json:"teams"` // team name -> player IDs
-	CreatedAt time.Time           `json:"created_at"`
-	Session   *GameSession        `json:"session,omitempty"`
+	ID         string              `json:"id"`
+	GameID     string              `json:"game_id"`
+	Teams      map[string][]string `json:"teams"` // team name -> player IDs
+	CreatedAt  time.Time           `json:"created_at"`
+	Session    *GameSession        `json:"session,omitempty"`
+	TeamTotals map[string]float64  `json:"team_totals,omitempty"` // team name -> total of the config's balance_by field
+	Roles      map[string]string   `json:"roles,omitempty"`       // player ID -> assigned role
+	Status     MatchStatus         `json:"status,omitempty"`      // awaiting_accept during the ready check, failed once dissolved
+	AcceptBy   *time.Time          `json:"accept_by,omitempty"`   // deadline for every player to accept
+	Accepted   []string            `json:"accepted,omitempty"`    // players who have accepted
+	RequestIDs []string            `json:"request_ids,omitempty"` // tickets in the match
+	Region     string              `json:"region,omitempty"`      // region with the lowest worst ping across the players
+	// PlayerMetadata holds the metadata each player queued with, used to match backfill players against them
+	PlayerMetadata map[string]map[string]interface{} `json:"player_metadata,omitempty"`
+}
+
+// RemovePlayers takes players out of the match's teams, roles and metadata
+func (m *MultiTeamMatch) RemovePlayers(playerIDs []string) {
+	if len(playerIDs) == 0 {
+		return
+	}
+	removed := make(map[string]bool, len(playerIDs))
+	for _, playerID := range playerIDs {
+		removed[playerID] = true
+		delete(m.Roles, playerID)
+		delete(m.PlayerMetadata, playerID)
+	}
+	for teamName, players := range m.Teams {
+		remaining := make([]string, 0, len(players))
+		for _, playerID := range players {
+			if !removed[playerID] {
+				remaining = append(remaining, playerID)
+			}
+		}
+		m.Teams[teamName] = remaining
+	}
 }
 
 // MatchStatusResponse represents the response for match status queries
 type MatchStatusResponse struct {
-	Status     MatchStatus  `json:"status"`
-	Team       *string      `json:"team,omitempty"`
-	Session    *GameSession `json:"session,omitempty"`
-	Error      *string      `json:"error,omitempty"`
-	MatchID    string       `json:"match_id,omitempty"`
-	Players    []string     `json:"players,omitempty"` // teammates
-	TeamName   string       `json:"team_name,omitempty"`
-	CreatedAt  string       `json:"created_at,omitempty"`
-	AllPlayers []string     `json:"all_players,omitempty"` // all players in match
+	Status     MatchStatus       `json:"status"`
+	Team       *string           `json:"team,omitempty"`
+	Session    *GameSession      `json:"session,omitempty"`
+	Error      *string           `json:"error,omitempty"`
+	MatchID    string            `json:"match_id,omitempty"`
+	Players    []string          `json:"players,omitempty"` // teammates
+	TeamName   string            `json:"team_name,omitempty"`
+	CreatedAt  string            `json:"created_at,omitempty"`
+	AllPlayers []string          `json:"all_players,omitempty"` // all players in match
+	Role       string            `json:"role,omitempty"`        // role assigned to the requesting player
+	AcceptBy   string            `json:"accept_by,omitempty"`   // deadline to accept while awaiting_accept
+	Roles      map[string]string `json:"roles,omitempty"`       // player ID -> assigned role, for all players in match
+	Region     string            `json:"region,omitempty"`      // region chosen to host the match
 }
 
 // AllocationRequest represents a request to allocate a game session
@@ -98,6 +307,7 @@ type AllocationRequest struct {
 	GameID   string   `json:"game_id"`
 	Players  []string `json:"players"`
 	TeamName string   `json:"team_name"`
+	Region   string   `json:"region,omitempty"` // region to place the session in
 }
 
 // AllocationResponse represents the response from the allocation service
@@ -119,6 +329,23 @@ func NewMatchRequest(playerID, gameID string, metadata map[string]interface{}) *
 	}
 }
 
+// NewBackfillRequest creates a backfill ticket requesting slots replacement players for a team of match,
+// replacing the departed players
+func NewBackfillRequest(match *MultiTeamMatch, teamName string, slots int, departed []string) *MatchRequest {
+	return &MatchRequest{
+		ID:        uuid.New().String(),
+		GameID:    match.GameID,
+		CreatedAt: time.Now(),
+		Status:    StatusPending,
+		Backfill: &BackfillTarget{
+			MatchID:  match.ID,
+			TeamName: teamName,
+			Slots:    slots,
+			Departed: departed,
+		},
+	}
+}
+
 // NewMatch creates a new match with a generated ID
 func NewMatch(gameID, teamName string, players []string) *Match {
 	return &Match{
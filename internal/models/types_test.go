@@ -29,6 +29,46 @@ func TestNewMatch(t *testing.T) {
 	assert.WithinDuration(t, time.Now(), match.CreatedAt, time.Second)
 }
 
+func TestMatchRequest_Party(t *testing.T) {
+	req := NewMatchRequest("p1", "g1", map[string]interface{}{"mmr": 1500})
+	assert.Equal(t, 1, req.Size())
+	assert.Equal(t, []string{"p1"}, req.PlayerIDs())
+	assert.Equal(t, []*MatchRequest{req}, req.MemberRequests())
+
+	req.Members = []PartyMember{
+		{PlayerID: "p2", Metadata: map[string]interface{}{"mmr": 1400}},
+		{PlayerID: "p3", Metadata: map[string]interface{}{"mmr": 1600}},
+	}
+	assert.Equal(t, 3, req.Size())
+	assert.Equal(t, []string{"p1", "p2", "p3"}, req.PlayerIDs())
+
+	members := req.MemberRequests()
+	assert.Len(t, members, 3)
+	assert.Equal(t, "p2", members[1].PlayerID)
+	assert.Equal(t, req.ID, members[1].ID)
+	assert.Equal(t, req.CreatedAt, members[1].CreatedAt)
+	assert.Equal(t, 1400, members[1].Metadata["mmr"])
+}
+
+func TestMatchRequest_Roles(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     []string
+	}{
+		{name: "List", metadata: map[string]interface{}{RolesField: []interface{}{"tank", "dps"}}, want: []string{"tank", "dps"}},
+		{name: "Single role", metadata: map[string]interface{}{RolesField: "healer"}, want: []string{"healer"}},
+		{name: "No roles", metadata: map[string]interface{}{"level": 10}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := NewMatchRequest("p1", "g1", tt.metadata)
+			assert.Equal(t, tt.want, req.Roles())
+		})
+	}
+}
+
 func TestMatchRequest_JSON(t *testing.T) {
 	req := &MatchRequest{
 		ID:        "id1",
@@ -53,7 +93,7 @@ func TestGameConfig_JSON(t *testing.T) {
 	cfg := &GameConfig{
 		GameID: "g1",
 		Teams:  []Team{{Name: "red", Size: 2}},
-		Rules:  []Rule{{Field: "level", Min: intPtr(10), Strict: true, Priority: 1}},
+		Rules:  []Rule{{Field: "level", Min: floatPtr(10), Strict: true, Priority: 1}},
 		UpdatedAt: time.Now().UTC().Truncate(time.Second),
 	}
 	data, err := json.Marshal(cfg)
@@ -67,6 +107,18 @@ func TestGameConfig_JSON(t *testing.T) {
 	assert.Equal(t, cfg.Rules[0].Strict, out.Rules[0].Strict)
 }
 
+func TestRule_GroupJSON(t *testing.T) {
+	data := []byte(`{"any": [{"field": "region", "equals": "us-west"}, {"not": {"field": "latency_us_west", "min": 60}}], "relax_after": 30}`)
+
+	var rule Rule
+	assert.NoError(t, json.Unmarshal(data, &rule))
+	assert.True(t, rule.IsGroup())
+	assert.Len(t, rule.Any, 2)
+	assert.False(t, rule.Any[0].IsGroup())
+	assert.Equal(t, "latency_us_west", rule.Any[1].Not.Field)
+	assert.Equal(t, 30, *rule.RelaxAfter)
+}
+
 func TestMatch_JSON(t *testing.T) {
 	sess := &GameSession{IP: "1.2.3.4", Port: 1234, ID: "sess1"}
 	match := &Match{
@@ -88,4 +140,16 @@ func TestMatch_JSON(t *testing.T) {
 	assert.Equal(t, match.Session.IP, out.Session.IP)
 }
 
-func intPtr(i int) *int { return &i } 
\ No newline at end of file
+func floatPtr(f float64) *float64 { return &f } 
+func TestMultiTeamMatch_RemovePlayers(t *testing.T) {
+	match := &MultiTeamMatch{
+		Teams:          map[string][]string{"red": {"p1", "p2"}, "blue": {"p3"}},
+		Roles:          map[string]string{"p1": "tank", "p2": "dps", "p3": "tank"},
+		PlayerMetadata: map[string]map[string]interface{}{"p1": {"mmr": 1500}, "p2": {"mmr": 1600}},
+	}
+	match.RemovePlayers([]string{"p2"})
+
+	assert.Equal(t, map[string][]string{"red": {"p1"}, "blue": {"p3"}}, match.Teams)
+	assert.Equal(t, map[string]string{"p1": "tank", "p3": "tank"}, match.Roles)
+	assert.NotContains(t, match.PlayerMetadata, "p2")
+}
@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GameSource lists the games that matchmaking should run for
+type GameSource interface {
+	GetGameIDs(ctx context.Context) ([]string, error)
+}
+
+// ProcessFunc runs a single matchmaking pass for a game
+type ProcessFunc func(ctx context.Context, gameID string) error
+
+// Scheduler periodically runs matchmaking for every game with a stored configuration.
+// Each game is processed by its own loop, so a slow or failing game does not delay the others.
+type Scheduler struct {
+	games    GameSource
+	process  ProcessFunc
+	interval time.Duration
+	logger   *logrus.Logger
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a new scheduler that runs process for each game every interval
+func NewScheduler(games GameSource, process ProcessFunc, interval time.Duration, logger *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		games:    games,
+		process:  process,
+		interval: interval,
+		logger:   logger,
+		running:  make(map[string]context.CancelFunc),
+	}
+}
+
+// Start begins game discovery and per-game processing in the background
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.mu.Lock()
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.discoverLoop(ctx)
+}
+
+// Stop stops all game loops and waits for in-flight matchmaking passes to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+// Games returns the IDs of the games currently being processed
+func (s *Scheduler) Games() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	gameIDs := make([]string, 0, len(s.running))
+	for gameID := range s.running {
+		gameIDs = append(gameIDs, gameID)
+	}
+	return gameIDs
+}
+
+// discoverLoop periodically looks for new or removed games
+func (s *Scheduler) discoverLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.discover(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.discover(ctx)
+		}
+	}
+}
+
+// discover starts a loop for every new game and stops loops for games that no longer exist
+func (s *Scheduler) discover(ctx context.Context) {
+	gameIDs, err := s.games.GetGameIDs(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.WithError(err).Error("Failed to discover games for matchmaking")
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	seen := make(map[string]bool, len(gameIDs))
+	for _, gameID := range gameIDs {
+		seen[gameID] = true
+		if _, ok := s.running[gameID]; ok {
+			continue
+		}
+
+		gameCtx, cancel := context.WithCancel(ctx)
+		s.running[gameID] = cancel
+		s.wg.Add(1)
+		go s.runGame(gameCtx, gameID)
+
+		s.logger.WithField("game_id", gameID).Info("Started scheduled matchmaking")
+	}
+
+	for gameID, cancel := range s.running {
+		if !seen[gameID] {
+			cancel()
+			delete(s.running, gameID)
+			s.logger.WithField("game_id", gameID).Info("Stopped scheduled matchmaking")
+		}
+	}
+}
+
+// runGame processes a single game on every tick until its context is cancelled
+func (s *Scheduler) runGame(ctx context.Context, gameID string) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.process(ctx, gameID); err != nil && ctx.Err() == nil {
+				s.logger.WithError(err).WithField("game_id", gameID).Error("Scheduled matchmaking failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGameSource struct {
+	mu      sync.Mutex
+	gameIDs []string
+}
+
+func (f *fakeGameSource) GetGameIDs(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.gameIDs...), nil
+}
+
+func (f *fakeGameSource) set(gameIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gameIDs = gameIDs
+}
+
+type processCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (p *processCounter) process(ctx context.Context, gameID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counts[gameID]++
+	return nil
+}
+
+func (p *processCounter) count(gameID string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.counts[gameID]
+}
+
+func TestScheduler_ProcessesEveryGame(t *testing.T) {
+	games := &fakeGameSource{gameIDs: []string{"game-1v1", "game-1v3"}}
+	counter := &processCounter{counts: make(map[string]int)}
+
+	s := NewScheduler(games, counter.process, 10*time.Millisecond, logrus.New())
+	s.Start(context.Background())
+	defer s.Stop()
+
+	assert.Eventually(t, func() bool {
+		return counter.count("game-1v1") >= 2 && counter.count("game-1v3") >= 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestScheduler_DiscoversAndDropsGames(t *testing.T) {
+	games := &fakeGameSource{gameIDs: []string{"game-1v1"}}
+	counter := &processCounter{counts: make(map[string]int)}
+
+	s := NewScheduler(games, counter.process, 10*time.Millisecond, logrus.New())
+	s.Start(context.Background())
+	defer s.Stop()
+
+	assert.Eventually(t, func() bool {
+		return len(s.Games()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	games.set("game-1v3")
+	assert.Eventually(t, func() bool {
+		gameIDs := s.Games()
+		return len(gameIDs) == 1 && gameIDs[0] == "game-1v3"
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return counter.count("game-1v3") >= 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestScheduler_StopWaitsForLoops(t *testing.T) {
+	games := &fakeGameSource{gameIDs: []string{"game-1v1"}}
+	counter := &processCounter{counts: make(map[string]int)}
+
+	s := NewScheduler(games, counter.process, 10*time.Millisecond, logrus.New())
+	s.Start(context.Background())
+
+	assert.Eventually(t, func() bool {
+		return counter.count("game-1v1") >= 1
+	}, time.Second, 5*time.Millisecond)
+
+	s.Stop()
+	stopped := counter.count("game-1v1")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, counter.count("game-1v1"))
+}
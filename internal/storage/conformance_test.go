@@ -0,0 +1,35 @@
+package storage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mm-rules/matchmaking/internal/storage"
+	"github.com/mm-rules/matchmaking/internal/storage/storagetest"
+)
+
+func TestRedisStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		mr := miniredis.RunT(t)
+		rs := storage.NewRedisStorage(mr.Addr(), "", 0)
+		t.Cleanup(func() { rs.Close() })
+
+		return storagetest.Backend{
+			Storage:     rs,
+			FastForward: mr.FastForward,
+		}
+	})
+}
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		now := time.Now()
+		ms := storage.NewMemoryStorageWithClock(func() time.Time { return now })
+
+		return storagetest.Backend{
+			Storage:     ms,
+			FastForward: func(d time.Duration) { now = now.Add(d) },
+		}
+	})
+}
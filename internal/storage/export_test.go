@@ -0,0 +1,11 @@
+package storage
+
+import "time"
+
+// NewMemoryStorageWithClock creates a MemoryStorage that reads the time from now,
+// so tests outside the package can control expiry
+func NewMemoryStorageWithClock(now func() time.Time) *MemoryStorage {
+	ms := NewMemoryStorage()
+	ms.now = now
+	return ms
+}
@@ -0,0 +1,664 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+)
+
+// memoryItem is a stored value with an optional expiry
+type memoryItem struct {
+	data      []byte
+	expiresAt time.Time // zero means no expiry
+}
+
+// MemoryStorage is a concurrency-safe, in-process Storage implementation.
+// It mirrors the key layout and expiry semantics of RedisStorage and is intended
+// for tests and single-node deployments.
+type MemoryStorage struct {
+	mu     sync.Mutex
+	items  map[string]memoryItem
+	queues map[string][]string // queue key -> request IDs, newest first
+	// readyChecks maps a game ID to its matches awaiting acceptance and their accept deadlines
+	readyChecks map[string]map[string]time.Time
+	now         func() time.Time
+}
+
+// NewMemoryStorage creates a new in-memory storage instance
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		items:       make(map[string]memoryItem),
+		queues:      make(map[string][]string),
+		readyChecks: make(map[string]map[string]time.Time),
+		now:         time.Now,
+	}
+}
+
+// Close releases the storage; it is a no-op for in-memory storage
+func (ms *MemoryStorage) Close() error {
+	return nil
+}
+
+// Ping always succeeds for in-memory storage
+func (ms *MemoryStorage) Ping(ctx context.Context) error {
+	return nil
+}
+
+// set stores a value under key, expiring after ttl (0 means never). Callers must hold ms.mu.
+func (ms *MemoryStorage) set(key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	item := memoryItem{data: data}
+	if ttl > 0 {
+		item.expiresAt = ms.now().Add(ttl)
+	}
+	ms.items[key] = item
+	return nil
+}
+
+// get loads the value stored under key into out, dropping it if expired. Callers must hold ms.mu.
+func (ms *MemoryStorage) get(key string, out interface{}) (bool, error) {
+	item, ok := ms.items[key]
+	if !ok {
+		return false, nil
+	}
+	if ms.expired(item) {
+		delete(ms.items, key)
+		return false, nil
+	}
+	return true, json.Unmarshal(item.data, out)
+}
+
+// exists reports whether an unexpired value is stored under key. Callers must hold ms.mu.
+func (ms *MemoryStorage) exists(key string) bool {
+	item, ok := ms.items[key]
+	return ok && !ms.expired(item)
+}
+
+// expired reports whether item has passed its expiry
+func (ms *MemoryStorage) expired(item memoryItem) bool {
+	return !item.expiresAt.IsZero() && !ms.now().Before(item.expiresAt)
+}
+
+// removeFromQueue removes every occurrence of requestID from a queue. Callers must hold ms.mu.
+func (ms *MemoryStorage) removeFromQueue(queueKey, requestID string) int {
+	queue := ms.queues[queueKey]
+	kept := queue[:0]
+	removed := 0
+	for _, id := range queue {
+		if id == requestID {
+			removed++
+			continue
+		}
+		kept = append(kept, id)
+	}
+
+	if len(kept) == 0 {
+		delete(ms.queues, queueKey)
+	} else {
+		ms.queues[queueKey] = kept
+	}
+	return removed
+}
+
+// StoreMatchRequest stores a match request and adds it to its game queue
+func (ms *MemoryStorage) StoreMatchRequest(ctx context.Context, request *models.MatchRequest) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := fmt.Sprintf("match_request:%s", request.ID)
+	if err := ms.set(key, request, RequestTTL(request)); err != nil {
+		return fmt.Errorf("failed to marshal match request: %w", err)
+	}
+
+	queueKey := fmt.Sprintf("game_queue:%s", request.GameID)
+	ms.queues[queueKey] = append([]string{request.ID}, ms.queues[queueKey]...)
+
+	return nil
+}
+
+// GetMatchRequest retrieves a match request by ID
+func (ms *MemoryStorage) GetMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.getMatchRequest(requestID)
+}
+
+// getMatchRequest retrieves a match request by ID. Callers must hold ms.mu.
+func (ms *MemoryStorage) getMatchRequest(requestID string) (*models.MatchRequest, error) {
+	var request models.MatchRequest
+	found, err := ms.get(fmt.Sprintf("match_request:%s", requestID), &request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal match request: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrRequestNotFound, requestID)
+	}
+	return &request, nil
+}
+
+// RefreshMatchRequest resets a match request's expiry to its TTL
+func (ms *MemoryStorage) RefreshMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	request, err := ms.getMatchRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("match_request:%s", requestID)
+	item := ms.items[key]
+	item.expiresAt = ms.now().Add(RequestTTL(request))
+	ms.items[key] = item
+
+	return request, nil
+}
+
+// CancelMatchRequest marks a pending match request cancelled and removes it from its game queue
+func (ms *MemoryStorage) CancelMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	request, err := ms.getMatchRequest(requestID)
+	if err != nil {
+		return nil, false, err
+	}
+	if request.Status == models.StatusCancelled {
+		return request, false, nil
+	}
+	if request.Status != models.StatusPending {
+		return nil, false, fmt.Errorf("%w: %s is %s", ErrRequestClaimed, requestID, request.Status)
+	}
+
+	request.Status = models.StatusCancelled
+	if err := ms.set(fmt.Sprintf("match_request:%s", requestID), request, RequestTTL(request)); err != nil {
+		return nil, false, fmt.Errorf("failed to marshal match request: %w", err)
+	}
+	ms.removeFromQueue(fmt.Sprintf("game_queue:%s", request.GameID), requestID)
+	if err := ms.set(fmt.Sprintf("match_status:%s", requestID), &models.MatchStatusResponse{Status: models.StatusCancelled}, matchStatusTTL); err != nil {
+		return nil, false, fmt.Errorf("failed to marshal match status: %w", err)
+	}
+
+	return request, true, nil
+}
+
+// GetGameQueue retrieves all pending match requests for a game, pruning expired ones from the queue
+func (ms *MemoryStorage) GetGameQueue(ctx context.Context, gameID string) ([]*models.MatchRequest, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	queueKey := fmt.Sprintf("game_queue:%s", gameID)
+	var requests []*models.MatchRequest
+	var missing []string
+	for _, requestID := range ms.queues[queueKey] {
+		if !ms.exists(fmt.Sprintf("match_request:%s", requestID)) {
+			missing = append(missing, requestID)
+			continue
+		}
+		request, err := ms.getMatchRequest(requestID)
+		if err != nil {
+			// Skip invalid requests
+			continue
+		}
+		requests = append(requests, request)
+	}
+
+	// Prune expired requests from the queue, as RedisStorage does
+	for _, requestID := range missing {
+		ms.removeFromQueue(queueKey, requestID)
+	}
+
+	return requests, nil
+}
+
+// RemoveFromQueue removes a match request from the game queue
+func (ms *MemoryStorage) RemoveFromQueue(ctx context.Context, gameID, requestID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.removeFromQueue(fmt.Sprintf("game_queue:%s", gameID), requestID)
+	return nil
+}
+
+// UpdateMatchRequestStatus updates the status of a match request
+func (ms *MemoryStorage) UpdateMatchRequestStatus(ctx context.Context, requestID string, status models.MatchStatus) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	request, err := ms.getMatchRequest(requestID)
+	if err != nil {
+		return err
+	}
+
+	request.Status = status
+	if err := ms.set(fmt.Sprintf("match_request:%s", request.ID), request, RequestTTL(request)); err != nil {
+		return fmt.Errorf("failed to marshal match request: %w", err)
+	}
+	return nil
+}
+
+// StoreGameConfig stores a game configuration
+func (ms *MemoryStorage) StoreGameConfig(ctx context.Context, config *models.GameConfig) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	config.UpdatedAt = ms.now()
+	if err := ms.set(fmt.Sprintf("game_config:%s", config.GameID), config, 0); err != nil {
+		return fmt.Errorf("failed to marshal game config: %w", err)
+	}
+	return nil
+}
+
+// GetGameConfig retrieves a game configuration
+func (ms *MemoryStorage) GetGameConfig(ctx context.Context, gameID string) (*models.GameConfig, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var config models.GameConfig
+	found, err := ms.get(fmt.Sprintf("game_config:%s", gameID), &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game config: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("game config not found: %s", gameID)
+	}
+	return &config, nil
+}
+
+// GetGameIDs returns the IDs of all games that have a stored configuration
+func (ms *MemoryStorage) GetGameIDs(ctx context.Context) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.gameIDs(), nil
+}
+
+// gameIDs returns the IDs of all stored game configurations. Callers must hold ms.mu.
+func (ms *MemoryStorage) gameIDs() []string {
+	var gameIDs []string
+	for key := range ms.items {
+		if gameID, ok := strings.CutPrefix(key, "game_config:"); ok {
+			gameIDs = append(gameIDs, gameID)
+		}
+	}
+	return gameIDs
+}
+
+// StoreMatch stores a completed match
+func (ms *MemoryStorage) StoreMatch(ctx context.Context, match *models.Match) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.set(fmt.Sprintf("match:%s", match.ID), match, matchTTL); err != nil {
+		return fmt.Errorf("failed to marshal match: %w", err)
+	}
+	return nil
+}
+
+// GetMatch retrieves a match by ID
+func (ms *MemoryStorage) GetMatch(ctx context.Context, matchID string) (*models.Match, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var match models.Match
+	found, err := ms.get(fmt.Sprintf("match:%s", matchID), &match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal match: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("match not found: %s", matchID)
+	}
+	return &match, nil
+}
+
+// StoreMatchStatus stores match status information
+func (ms *MemoryStorage) StoreMatchStatus(ctx context.Context, requestID string, status *models.MatchStatusResponse) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.set(fmt.Sprintf("match_status:%s", requestID), status, matchStatusTTL); err != nil {
+		return fmt.Errorf("failed to marshal match status: %w", err)
+	}
+	return nil
+}
+
+// GetMatchStatus retrieves match status information
+func (ms *MemoryStorage) GetMatchStatus(ctx context.Context, requestID string) (*models.MatchStatusResponse, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var status models.MatchStatusResponse
+	found, err := ms.get(fmt.Sprintf("match_status:%s", requestID), &status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal match status: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("match status not found: %s", requestID)
+	}
+	return &status, nil
+}
+
+// CleanupExpiredRequests removes expired match requests from every game queue
+// and drops any other expired records
+func (ms *MemoryStorage) CleanupExpiredRequests(ctx context.Context) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, gameID := range ms.gameIDs() {
+		queueKey := fmt.Sprintf("game_queue:%s", gameID)
+		for _, requestID := range append([]string(nil), ms.queues[queueKey]...) {
+			if !ms.exists(fmt.Sprintf("match_request:%s", requestID)) {
+				ms.removeFromQueue(queueKey, requestID)
+			}
+		}
+	}
+
+	for key, item := range ms.items {
+		if ms.expired(item) {
+			delete(ms.items, key)
+		}
+	}
+
+	return nil
+}
+
+// GetStats returns basic statistics about the storage
+func (ms *MemoryStorage) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	totalRequests := 0
+	for _, queue := range ms.queues {
+		totalRequests += len(queue)
+	}
+
+	return map[string]interface{}{
+		"total_game_configs":     len(ms.gameIDs()),
+		"total_game_queues":      len(ms.queues),
+		"total_pending_requests": totalRequests,
+	}, nil
+}
+
+// StoreRequestMatchMapping stores a mapping from requestID to matchID
+func (ms *MemoryStorage) StoreRequestMatchMapping(ctx context.Context, requestID, matchID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	return ms.set(fmt.Sprintf("request_match:%s", requestID), matchID, matchTTL)
+}
+
+// GetMatchIDForRequest retrieves the matchID for a given requestID
+func (ms *MemoryStorage) GetMatchIDForRequest(ctx context.Context, requestID string) (string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var matchID string
+	found, err := ms.get(fmt.Sprintf("request_match:%s", requestID), &matchID)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("request match mapping not found: %s", requestID)
+	}
+	return matchID, nil
+}
+
+// StoreMultiTeamMatch stores a MultiTeamMatch
+func (ms *MemoryStorage) StoreMultiTeamMatch(ctx context.Context, match *models.MultiTeamMatch) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if err := ms.set(fmt.Sprintf("multi_team_match:%s", match.ID), match, matchTTL); err != nil {
+		return fmt.Errorf("failed to marshal multi-team match: %w", err)
+	}
+	return nil
+}
+
+// GetMultiTeamMatch retrieves a MultiTeamMatch by ID
+func (ms *MemoryStorage) GetMultiTeamMatch(ctx context.Context, matchID string) (*models.MultiTeamMatch, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var match models.MultiTeamMatch
+	found, err := ms.get(fmt.Sprintf("multi_team_match:%s", matchID), &match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal multi-team match: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
+	}
+	return &match, nil
+}
+
+// UpdateMultiTeamMatch applies update to a stored match while holding the storage lock
+func (ms *MemoryStorage) UpdateMultiTeamMatch(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) error) (*models.MultiTeamMatch, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := fmt.Sprintf("multi_team_match:%s", matchID)
+	var match models.MultiTeamMatch
+	found, err := ms.get(key, &match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal multi-team match: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
+	}
+	if err := update(&match); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal multi-team match: %w", err)
+	}
+	item := ms.items[key]
+	item.data = data
+	ms.items[key] = item
+	return &match, nil
+}
+
+// RequeueMatchRequest stores a request as pending and pushes it back onto its game queue
+func (ms *MemoryStorage) RequeueMatchRequest(ctx context.Context, request *models.MatchRequest) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	requeued := *request
+	requeued.Status = models.StatusPending
+	if err := ms.set(fmt.Sprintf("match_request:%s", request.ID), &requeued, RequestTTL(request)); err != nil {
+		return fmt.Errorf("failed to marshal match request: %w", err)
+	}
+
+	queueKey := fmt.Sprintf("game_queue:%s", request.GameID)
+	ms.removeFromQueue(queueKey, request.ID)
+	ms.queues[queueKey] = append([]string{request.ID}, ms.queues[queueKey]...)
+	delete(ms.items, fmt.Sprintf("match_status:%s", request.ID))
+	delete(ms.items, fmt.Sprintf("request_match:%s", request.ID))
+	return nil
+}
+
+// CommitMatch atomically stores a match and moves every request in it out of the queue
+func (ms *MemoryStorage) CommitMatch(ctx context.Context, match *models.MultiTeamMatch, statuses map[string]*models.MatchStatusResponse) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	requests, err := ms.pendingRequests(statuses)
+	if err != nil {
+		return err
+	}
+	if err := ms.set(fmt.Sprintf("multi_team_match:%s", match.ID), match, matchTTL); err != nil {
+		return fmt.Errorf("failed to marshal multi-team match: %w", err)
+	}
+	return ms.claimRequests(match, requests, statuses)
+}
+
+// CommitBackfill applies update to a stored match, keeping its expiry, and claims the requests in the
+// statuses update returns, all under the storage lock
+func (ms *MemoryStorage) CommitBackfill(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error)) (*models.MultiTeamMatch, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := fmt.Sprintf("multi_team_match:%s", matchID)
+	var match models.MultiTeamMatch
+	found, err := ms.get(key, &match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal multi-team match: %w", err)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
+	}
+	statuses, err := update(&match)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := ms.pendingRequests(statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(&match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal multi-team match: %w", err)
+	}
+	item := ms.items[key]
+	item.data = data
+	ms.items[key] = item
+	if err := ms.claimRequests(&match, requests, statuses); err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// pendingRequests loads the requests in statuses, failing with ErrRequestClaimed if any is missing or
+// no longer pending. Callers must hold ms.mu.
+func (ms *MemoryStorage) pendingRequests(statuses map[string]*models.MatchStatusResponse) ([]*models.MatchRequest, error) {
+	requests := make([]*models.MatchRequest, 0, len(statuses))
+	for requestID := range statuses {
+		request, err := ms.getMatchRequest(requestID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s not found", ErrRequestClaimed, requestID)
+		}
+		if request.Status != models.StatusPending {
+			return nil, fmt.Errorf("%w: %s is %s", ErrRequestClaimed, requestID, request.Status)
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+// claimRequests marks requests matched by match, removes them from the game queue and stores their
+// request -> match mappings and statuses. Callers must hold ms.mu.
+func (ms *MemoryStorage) claimRequests(match *models.MultiTeamMatch, requests []*models.MatchRequest, statuses map[string]*models.MatchStatusResponse) error {
+	queueKey := fmt.Sprintf("game_queue:%s", match.GameID)
+	for _, request := range requests {
+		request.Status = models.StatusMatched
+		if err := ms.set(fmt.Sprintf("match_request:%s", request.ID), request, claimedRequestTTL(request, match, ms.now())); err != nil {
+			return fmt.Errorf("failed to marshal match request: %w", err)
+		}
+		ms.removeFromQueue(queueKey, request.ID)
+		if err := ms.set(fmt.Sprintf("request_match:%s", request.ID), match.ID, matchTTL); err != nil {
+			return err
+		}
+		if err := ms.set(fmt.Sprintf("match_status:%s", request.ID), statuses[request.ID], matchStatusTTL); err != nil {
+			return fmt.Errorf("failed to marshal match status: %w", err)
+		}
+	}
+
+	if match.Status == models.StatusAwaitingAccept && match.AcceptBy != nil {
+		if ms.readyChecks[match.GameID] == nil {
+			ms.readyChecks[match.GameID] = make(map[string]time.Time)
+		}
+		ms.readyChecks[match.GameID][match.ID] = *match.AcceptBy
+	}
+
+	return nil
+}
+
+// ExpiredReadyChecks returns the game's ready checks whose deadline has passed, leaving them in place
+func (ms *MemoryStorage) ExpiredReadyChecks(ctx context.Context, gameID string, now time.Time) ([]string, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	var matchIDs []string
+	for matchID, acceptBy := range ms.readyChecks[gameID] {
+		if !acceptBy.After(now) {
+			matchIDs = append(matchIDs, matchID)
+		}
+	}
+	deadlines := ms.readyChecks[gameID]
+	sort.Slice(matchIDs, func(i, j int) bool {
+		if a, b := deadlines[matchIDs[i]], deadlines[matchIDs[j]]; !a.Equal(b) {
+			return a.Before(b)
+		}
+		return matchIDs[i] < matchIDs[j]
+	})
+	return matchIDs, nil
+}
+
+// RemoveReadyCheck removes a match from the game's ready checks
+func (ms *MemoryStorage) RemoveReadyCheck(ctx context.Context, gameID, matchID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.readyChecks[gameID], matchID)
+	if len(ms.readyChecks[gameID]) == 0 {
+		delete(ms.readyChecks, gameID)
+	}
+	return nil
+}
+
+// lockOwner returns the owner of an unexpired game lock, or "" if the lock is free. Callers must hold ms.mu.
+func (ms *MemoryStorage) lockOwner(key string) string {
+	var owner string
+	if found, err := ms.get(key, &owner); err != nil || !found {
+		return ""
+	}
+	return owner
+}
+
+// AcquireGameLock takes the matchmaking lease for a game
+func (ms *MemoryStorage) AcquireGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := fmt.Sprintf("game_lock:%s", gameID)
+	if ms.lockOwner(key) != "" {
+		return false, nil
+	}
+	return true, ms.set(key, owner, ttl)
+}
+
+// RenewGameLock extends the matchmaking lease for a game if owner still holds it
+func (ms *MemoryStorage) RenewGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := fmt.Sprintf("game_lock:%s", gameID)
+	if ms.lockOwner(key) != owner {
+		return false, nil
+	}
+	return true, ms.set(key, owner, ttl)
+}
+
+// ReleaseGameLock gives up the matchmaking lease for a game if owner holds it
+func (ms *MemoryStorage) ReleaseGameLock(ctx context.Context, gameID, owner string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	key := fmt.Sprintf("game_lock:%s", gameID)
+	if ms.lockOwner(key) == owner {
+		delete(ms.items, key)
+	}
+	return nil
+}
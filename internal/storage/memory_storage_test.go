@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Storage = (*MemoryStorage)(nil)
+
+// fakeClock is a manually advanced clock for expiry tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestMemoryStorage() (*MemoryStorage, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	ms := NewMemoryStorage()
+	ms.now = clock.Now
+	return ms, clock
+}
+
+func TestMemoryStorage_MatchRequestRoundTrip(t *testing.T) {
+	ms, _ := newTestMemoryStorage()
+	ctx := context.Background()
+
+	request := models.NewMatchRequest("p1", "g1", map[string]interface{}{"level": 10})
+	require.NoError(t, ms.StoreMatchRequest(ctx, request))
+
+	got, err := ms.GetMatchRequest(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, request.PlayerID, got.PlayerID)
+	assert.Equal(t, float64(10), got.Metadata["level"])
+
+	queue, err := ms.GetGameQueue(ctx, "g1")
+	require.NoError(t, err)
+	assert.Len(t, queue, 1)
+
+	require.NoError(t, ms.UpdateMatchRequestStatus(ctx, request.ID, models.StatusMatched))
+	got, err = ms.GetMatchRequest(ctx, request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusMatched, got.Status)
+
+	require.NoError(t, ms.RemoveFromQueue(ctx, "g1", request.ID))
+	queue, err = ms.GetGameQueue(ctx, "g1")
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+}
+
+func TestMemoryStorage_ExpiresMatchRequests(t *testing.T) {
+	ms, clock := newTestMemoryStorage()
+	ctx := context.Background()
+
+	require.NoError(t, ms.StoreGameConfig(ctx, &models.GameConfig{GameID: "g1", Teams: []models.Team{{Name: "red", Size: 1}}}))
+	request := models.NewMatchRequest("p1", "g1", nil)
+	require.NoError(t, ms.StoreMatchRequest(ctx, request))
+	require.NoError(t, ms.StoreMatchStatus(ctx, request.ID, &models.MatchStatusResponse{Status: models.StatusMatched}))
+
+	clock.Advance(matchRequestTTL)
+
+	_, err := ms.GetMatchRequest(ctx, request.ID)
+	assert.Error(t, err)
+	_, err = ms.GetMatchStatus(ctx, request.ID)
+	assert.NoError(t, err)
+
+	require.NoError(t, ms.CleanupExpiredRequests(ctx))
+	stats, err := ms.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats["total_pending_requests"])
+	assert.Equal(t, 1, stats["total_game_configs"])
+
+	clock.Advance(matchStatusTTL)
+	_, err = ms.GetMatchStatus(ctx, request.ID)
+	assert.Error(t, err)
+
+	_, err = ms.GetGameConfig(ctx, "g1")
+	assert.NoError(t, err)
+}
+
+func TestMemoryStorage_ConcurrentAccess(t *testing.T) {
+	ms, _ := newTestMemoryStorage()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := models.NewMatchRequest("p", "g1", nil)
+			_ = ms.StoreMatchRequest(ctx, request)
+			_, _ = ms.GetGameQueue(ctx, "g1")
+			_ = ms.RemoveFromQueue(ctx, "g1", request.ID)
+		}()
+	}
+	wg.Wait()
+
+	queue, err := ms.GetGameQueue(ctx, "g1")
+	require.NoError(t, err)
+	assert.Empty(t, queue)
+}
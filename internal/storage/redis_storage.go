@@ -4,12 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/mm-rules/matchmaking/internal/models"
 )
 
+// Index sets let RedisStorage enumerate games and queues without scanning the keyspace
+const (
+	gameConfigIndexKey = "game_configs" // set of game IDs with a stored configuration
+	gameQueueIndexKey  = "game_queues"  // set of game IDs that have had a queue
+)
+
 // RedisStorage handles data persistence using Redis
 type RedisStorage struct {
 	client *redis.Client
@@ -47,17 +54,16 @@ func (rs *RedisStorage) StoreMatchRequest(ctx context.Context, request *models.M
 		return fmt.Errorf("failed to marshal match request: %w", err)
 	}
 
-	// Set with expiration (60 seconds)
-	err = rs.client.Set(ctx, key, data, 60*time.Second).Err()
-	if err != nil {
-		return fmt.Errorf("failed to store match request: %w", err)
-	}
-
-	// Add to game-specific queue
+	// Set with the ticket's expiration, add to the game-specific queue and index the queue
 	queueKey := fmt.Sprintf("game_queue:%s", request.GameID)
-	err = rs.client.LPush(ctx, queueKey, request.ID).Err()
+	_, err = rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, RequestTTL(request))
+		pipe.LPush(ctx, queueKey, request.ID)
+		pipe.SAdd(ctx, gameQueueIndexKey, request.GameID)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to add to game queue: %w", err)
+		return fmt.Errorf("failed to store match request: %w", err)
 	}
 
 	return nil
@@ -69,7 +75,7 @@ func (rs *RedisStorage) GetMatchRequest(ctx context.Context, requestID string) (
 	data, err := rs.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, fmt.Errorf("match request not found: %s", requestID)
+			return nil, fmt.Errorf("%w: %s", ErrRequestNotFound, requestID)
 		}
 		return nil, fmt.Errorf("failed to get match request: %w", err)
 	}
@@ -82,27 +88,156 @@ func (rs *RedisStorage) GetMatchRequest(ctx context.Context, requestID string) (
 	return &request, nil
 }
 
+// RefreshMatchRequest resets a match request's expiry to its TTL
+func (rs *RedisStorage) RefreshMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, error) {
+	request, err := rs.GetMatchRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("match_request:%s", requestID)
+	refreshed, err := rs.client.Expire(ctx, key, RequestTTL(request)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh match request: %w", err)
+	}
+	if !refreshed {
+		// The request expired between the read and the refresh
+		return nil, fmt.Errorf("%w: %s", ErrRequestNotFound, requestID)
+	}
+
+	return request, nil
+}
+
+// CancelMatchRequest marks a pending match request cancelled and removes it from its game queue
+func (rs *RedisStorage) CancelMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, bool, error) {
+	key := fmt.Sprintf("match_request:%s", requestID)
+	var cancelled *models.MatchRequest
+	var changed bool
+
+	cancel := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return fmt.Errorf("%w: %s", ErrRequestNotFound, requestID)
+			}
+			return fmt.Errorf("failed to get match request: %w", err)
+		}
+
+		var request models.MatchRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			return fmt.Errorf("failed to unmarshal match request: %w", err)
+		}
+		if request.Status == models.StatusCancelled {
+			cancelled, changed = &request, false
+			return nil
+		}
+		if request.Status != models.StatusPending {
+			return fmt.Errorf("%w: %s is %s", ErrRequestClaimed, requestID, request.Status)
+		}
+
+		request.Status = models.StatusCancelled
+		requestData, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to marshal match request: %w", err)
+		}
+		statusData, err := json.Marshal(&models.MatchStatusResponse{Status: models.StatusCancelled})
+		if err != nil {
+			return fmt.Errorf("failed to marshal match status: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, requestData, RequestTTL(&request))
+			pipe.LRem(ctx, fmt.Sprintf("game_queue:%s", request.GameID), 0, requestID)
+			pipe.Set(ctx, fmt.Sprintf("match_status:%s", requestID), statusData, matchStatusTTL)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		cancelled, changed = &request, true
+		return nil
+	}
+
+	for attempt := 0; attempt < maxCommitAttempts; attempt++ {
+		err := rs.client.Watch(ctx, cancel, key)
+		if err == nil {
+			return cancelled, changed, nil
+		}
+		if err != redis.TxFailedErr {
+			return nil, false, err
+		}
+	}
+
+	return nil, false, fmt.Errorf("%w while cancelling %s", ErrConcurrentUpdate, requestID)
+}
+
+// queueBatchSize is the number of match requests loaded per MGET when reading a game queue
+const queueBatchSize = 500
+
 // GetGameQueue retrieves all pending match requests for a game
 func (rs *RedisStorage) GetGameQueue(ctx context.Context, gameID string) ([]*models.MatchRequest, error) {
+	requests, _, err := rs.loadQueue(ctx, gameID)
+	return requests, err
+}
+
+// loadQueue reads a game queue with batched MGETs instead of one GET per request.
+// Queue entries whose request has expired are pruned from the queue; it returns the
+// live requests and the number of entries pruned.
+func (rs *RedisStorage) loadQueue(ctx context.Context, gameID string) ([]*models.MatchRequest, int, error) {
 	queueKey := fmt.Sprintf("game_queue:%s", gameID)
 
 	// Get all request IDs in the queue
 	requestIDs, err := rs.client.LRange(ctx, queueKey, 0, -1).Result()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get game queue: %w", err)
+		return nil, 0, fmt.Errorf("failed to get game queue: %w", err)
 	}
 
 	var requests []*models.MatchRequest
-	for _, requestID := range requestIDs {
-		request, err := rs.GetMatchRequest(ctx, requestID)
+	var missing []string
+	for start := 0; start < len(requestIDs); start += queueBatchSize {
+		batch := requestIDs[start:min(start+queueBatchSize, len(requestIDs))]
+		keys := make([]string, len(batch))
+		for i, requestID := range batch {
+			keys[i] = fmt.Sprintf("match_request:%s", requestID)
+		}
+
+		values, err := rs.client.MGet(ctx, keys...).Result()
 		if err != nil {
-			// Skip invalid requests
-			continue
+			return nil, 0, fmt.Errorf("failed to get match requests: %w", err)
+		}
+
+		for i, value := range values {
+			data, ok := value.(string)
+			if !ok {
+				// The request has expired or was deleted
+				missing = append(missing, batch[i])
+				continue
+			}
+
+			var request models.MatchRequest
+			if err := json.Unmarshal([]byte(data), &request); err != nil {
+				// Skip invalid requests
+				continue
+			}
+			requests = append(requests, &request)
 		}
-		requests = append(requests, request)
 	}
 
-	return requests, nil
+	pruned := 0
+	if len(missing) > 0 {
+		pipe := rs.client.Pipeline()
+		removals := make([]*redis.IntCmd, len(missing))
+		for i, requestID := range missing {
+			removals[i] = pipe.LRem(ctx, queueKey, 0, requestID)
+		}
+		// Pruning is best effort; anything left behind is retried on the next read
+		_, _ = pipe.Exec(ctx)
+		for _, removal := range removals {
+			pruned += int(removal.Val())
+		}
+	}
+
+	return requests, pruned, nil
 }
 
 // RemoveFromQueue removes a match request from the game queue
@@ -133,8 +268,8 @@ func (rs *RedisStorage) UpdateMatchRequestStatus(ctx context.Context, requestID
 		return fmt.Errorf("failed to marshal match request: %w", err)
 	}
 
-	// Set with expiration (60 seconds) but don't add to queue
-	return rs.client.Set(ctx, key, data, 60*time.Second).Err()
+	// Set with the ticket's expiration but don't add to queue
+	return rs.client.Set(ctx, key, data, RequestTTL(request)).Err()
 }
 
 // StoreGameConfig stores a game configuration
@@ -147,8 +282,13 @@ func (rs *RedisStorage) StoreGameConfig(ctx context.Context, config *models.Game
 		return fmt.Errorf("failed to marshal game config: %w", err)
 	}
 
-	// Store without expiration (configs don't expire)
-	return rs.client.Set(ctx, key, data, 0).Err()
+	// Store without expiration (configs don't expire) and index the game
+	_, err = rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, data, 0)
+		pipe.SAdd(ctx, gameConfigIndexKey, config.GameID)
+		return nil
+	})
+	return err
 }
 
 // GetGameConfig retrieves a game configuration
@@ -170,6 +310,44 @@ func (rs *RedisStorage) GetGameConfig(ctx context.Context, gameID string) (*mode
 	return &config, nil
 }
 
+// GetGameIDs returns the IDs of all games that have a stored configuration
+func (rs *RedisStorage) GetGameIDs(ctx context.Context) ([]string, error) {
+	gameIDs, err := rs.client.SMembers(ctx, gameConfigIndexKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game config index: %w", err)
+	}
+	return gameIDs, nil
+}
+
+// RebuildIndexes adds every existing game config and queue to the index sets.
+// It walks the keyspace with SCAN, so it is safe to run against a live server;
+// it only needs to run once for data written before the indexes existed.
+func (rs *RedisStorage) RebuildIndexes(ctx context.Context) error {
+	indexes := []struct {
+		pattern  string
+		prefix   string
+		indexKey string
+	}{
+		{"game_config:*", "game_config:", gameConfigIndexKey},
+		{"game_queue:*", "game_queue:", gameQueueIndexKey},
+	}
+
+	for _, index := range indexes {
+		iter := rs.client.Scan(ctx, 0, index.pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			gameID := iter.Val()[len(index.prefix):]
+			if err := rs.client.SAdd(ctx, index.indexKey, gameID).Err(); err != nil {
+				return fmt.Errorf("failed to index %s: %w", iter.Val(), err)
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("failed to scan %s: %w", index.pattern, err)
+		}
+	}
+
+	return nil
+}
+
 // StoreMatch stores a completed match
 func (rs *RedisStorage) StoreMatch(ctx context.Context, match *models.Match) error {
 	key := fmt.Sprintf("match:%s", match.ID)
@@ -179,7 +357,7 @@ func (rs *RedisStorage) StoreMatch(ctx context.Context, match *models.Match) err
 	}
 
 	// Store with expiration (7 days)
-	return rs.client.Set(ctx, key, data, 7*24*time.Hour).Err()
+	return rs.client.Set(ctx, key, data, matchTTL).Err()
 }
 
 // GetMatch retrieves a match by ID
@@ -210,7 +388,7 @@ func (rs *RedisStorage) StoreMatchStatus(ctx context.Context, requestID string,
 	}
 
 	// Store with expiration (1 hour)
-	return rs.client.Set(ctx, key, data, time.Hour).Err()
+	return rs.client.Set(ctx, key, data, matchStatusTTL).Err()
 }
 
 // GetMatchStatus retrieves match status information
@@ -235,55 +413,20 @@ func (rs *RedisStorage) GetMatchStatus(ctx context.Context, requestID string) (*
 // CleanupExpiredRequests removes expired match requests
 func (rs *RedisStorage) CleanupExpiredRequests(ctx context.Context) error {
 	// Get all game configs to find active games
-	pattern := "game_config:*"
-	keys, err := rs.client.Keys(ctx, pattern).Result()
+	gameIDs, err := rs.GetGameIDs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get game config keys: %w", err)
+		return err
 	}
 
 	totalRemoved := 0
-	for _, key := range keys {
-		gameID := key[len("game_config:"):]
-		queueKey := fmt.Sprintf("game_queue:%s", gameID)
-
-		// Get all request IDs in the queue
-		requestIDs, err := rs.client.LRange(ctx, queueKey, 0, -1).Result()
+	for _, gameID := range gameIDs {
+		// Loading the queue prunes every expired request from it
+		_, removedCount, err := rs.loadQueue(ctx, gameID)
 		if err != nil {
 			fmt.Printf("[CLEANUP] Failed to get queue for %s: %v\n", gameID, err)
 			continue
 		}
 
-		if len(requestIDs) == 0 {
-			continue
-		}
-
-		fmt.Printf("[CLEANUP] Checking %d requests in queue for %s\n", len(requestIDs), gameID)
-		removedCount := 0
-
-		// Check each request and remove if expired
-		for _, requestID := range requestIDs {
-			requestKey := fmt.Sprintf("match_request:%s", requestID)
-			exists, err := rs.client.Exists(ctx, requestKey).Result()
-			if err != nil {
-				fmt.Printf("[CLEANUP] Error checking request %s: %v\n", requestID, err)
-				continue
-			}
-
-			if exists == 0 {
-				// Request doesn't exist, remove from queue
-				result := rs.client.LRem(ctx, queueKey, 0, requestID)
-				if result.Err() != nil {
-					fmt.Printf("[CLEANUP] Failed to remove request %s from queue: %v\n", requestID, result.Err())
-				} else {
-					count, _ := result.Result()
-					if count > 0 {
-						removedCount += int(count)
-						fmt.Printf("[CLEANUP] Removed expired request %s from queue\n", requestID)
-					}
-				}
-			}
-		}
-
 		if removedCount > 0 {
 			fmt.Printf("[CLEANUP] Removed %d expired requests from %s queue\n", removedCount, gameID)
 			totalRemoved += removedCount
@@ -302,25 +445,37 @@ func (rs *RedisStorage) GetStats(ctx context.Context) (map[string]interface{}, e
 	stats := make(map[string]interface{})
 
 	// Count game configs
-	configKeys, err := rs.client.Keys(ctx, "game_config:*").Result()
+	configCount, err := rs.client.SCard(ctx, gameConfigIndexKey).Result()
 	if err == nil {
-		stats["total_game_configs"] = len(configKeys)
+		stats["total_game_configs"] = int(configCount)
 	}
 
-	// Count active queues
-	queueKeys, err := rs.client.Keys(ctx, "game_queue:*").Result()
-	if err == nil {
-		stats["total_game_queues"] = len(queueKeys)
+	// Count active queues and total pending requests in one round trip
+	stats["total_pending_requests"] = 0
+	queueGameIDs, err := rs.client.SMembers(ctx, gameQueueIndexKey).Result()
+	if err != nil {
+		return stats, nil
 	}
 
-	// Count total pending requests
+	pipe := rs.client.Pipeline()
+	lengths := make([]*redis.IntCmd, len(queueGameIDs))
+	for i, gameID := range queueGameIDs {
+		lengths[i] = pipe.LLen(ctx, fmt.Sprintf("game_queue:%s", gameID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return stats, nil
+	}
+
+	activeQueues := 0
 	totalRequests := 0
-	for _, queueKey := range queueKeys {
-		count, err := rs.client.LLen(ctx, queueKey).Result()
-		if err == nil {
+	for _, length := range lengths {
+		// Emptied queues no longer exist in Redis and are not counted
+		if count := length.Val(); count > 0 {
+			activeQueues++
 			totalRequests += int(count)
 		}
 	}
+	stats["total_game_queues"] = activeQueues
 	stats["total_pending_requests"] = totalRequests
 
 	return stats, nil
@@ -329,7 +484,7 @@ func (rs *RedisStorage) GetStats(ctx context.Context) (map[string]interface{}, e
 // StoreRequestMatchMapping stores a mapping from requestID to matchID
 func (rs *RedisStorage) StoreRequestMatchMapping(ctx context.Context, requestID, matchID string) error {
 	key := fmt.Sprintf("request_match:%s", requestID)
-	return rs.client.Set(ctx, key, matchID, 7*24*time.Hour).Err()
+	return rs.client.Set(ctx, key, matchID, matchTTL).Err()
 }
 
 // GetMatchIDForRequest retrieves the matchID for a given requestID
@@ -349,7 +504,7 @@ func (rs *RedisStorage) StoreMultiTeamMatch(ctx context.Context, match *models.M
 	if err != nil {
 		return fmt.Errorf("failed to marshal multi-team match: %w", err)
 	}
-	return rs.client.Set(ctx, key, data, 7*24*time.Hour).Err()
+	return rs.client.Set(ctx, key, data, matchTTL).Err()
 }
 
 // GetMultiTeamMatch retrieves a MultiTeamMatch by ID
@@ -358,7 +513,7 @@ func (rs *RedisStorage) GetMultiTeamMatch(ctx context.Context, matchID string) (
 	data, err := rs.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, fmt.Errorf("multi-team match not found: %s", matchID)
+			return nil, fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
 		}
 		return nil, fmt.Errorf("failed to get multi-team match: %w", err)
 	}
@@ -368,3 +523,309 @@ func (rs *RedisStorage) GetMultiTeamMatch(ctx context.Context, matchID string) (
 	}
 	return &match, nil
 }
+
+// UpdateMultiTeamMatch applies update to a stored match inside a WATCH transaction, retrying if the
+// match is written concurrently
+func (rs *RedisStorage) UpdateMultiTeamMatch(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) error) (*models.MultiTeamMatch, error) {
+	key := fmt.Sprintf("multi_team_match:%s", matchID)
+	var updated *models.MultiTeamMatch
+
+	apply := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
+			}
+			return fmt.Errorf("failed to get multi-team match: %w", err)
+		}
+
+		var match models.MultiTeamMatch
+		if err := json.Unmarshal(data, &match); err != nil {
+			return fmt.Errorf("failed to unmarshal multi-team match: %w", err)
+		}
+		if err := update(&match); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(&match); err != nil {
+			return fmt.Errorf("failed to marshal multi-team match: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, redis.KeepTTL)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		updated = &match
+		return nil
+	}
+
+	for attempt := 0; attempt < maxCommitAttempts; attempt++ {
+		err := rs.client.Watch(ctx, apply, key)
+		if err == nil {
+			return updated, nil
+		}
+		if err != redis.TxFailedErr {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w while updating match %s", ErrConcurrentUpdate, matchID)
+}
+
+// RequeueMatchRequest stores a request as pending and pushes it back onto its game queue in one transaction
+func (rs *RedisStorage) RequeueMatchRequest(ctx context.Context, request *models.MatchRequest) error {
+	requeued := *request
+	requeued.Status = models.StatusPending
+	data, err := json.Marshal(&requeued)
+	if err != nil {
+		return fmt.Errorf("failed to marshal match request: %w", err)
+	}
+
+	queueKey := fmt.Sprintf("game_queue:%s", request.GameID)
+	_, err = rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf("match_request:%s", request.ID), data, RequestTTL(request))
+		pipe.LRem(ctx, queueKey, 0, request.ID)
+		pipe.LPush(ctx, queueKey, request.ID)
+		pipe.SAdd(ctx, gameQueueIndexKey, request.GameID)
+		pipe.Del(ctx, fmt.Sprintf("match_status:%s", request.ID), fmt.Sprintf("request_match:%s", request.ID))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to requeue match request: %w", err)
+	}
+	return nil
+}
+
+// maxCommitAttempts bounds how often CommitMatch retries after a concurrent write to a watched request
+const maxCommitAttempts = 3
+
+// CommitMatch atomically stores a match and moves every request in it out of the queue.
+// It watches the request keys so a concurrent claim by another replica aborts the transaction.
+func (rs *RedisStorage) CommitMatch(ctx context.Context, match *models.MultiTeamMatch, statuses map[string]*models.MatchStatusResponse) error {
+	matchData, err := json.Marshal(match)
+	if err != nil {
+		return fmt.Errorf("failed to marshal multi-team match: %w", err)
+	}
+	claims := newRequestClaims(statuses)
+
+	commit := func(tx *redis.Tx) error {
+		// Verify every request is still pending before writing anything
+		if err := claims.load(ctx, tx, match); err != nil {
+			return err
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, fmt.Sprintf("multi_team_match:%s", match.ID), matchData, matchTTL)
+			claims.write(ctx, pipe, match)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < maxCommitAttempts; attempt++ {
+		err := rs.client.Watch(ctx, commit, claims.keys...)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: concurrent update while committing match %s", ErrRequestClaimed, match.ID)
+}
+
+// CommitBackfill applies update to a stored match and claims the requests in the statuses it returns in one
+// WATCH transaction on the match and request keys, keeping the match's expiry and retrying if either changes
+func (rs *RedisStorage) CommitBackfill(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error)) (*models.MultiTeamMatch, error) {
+	key := fmt.Sprintf("multi_team_match:%s", matchID)
+	var updated *models.MultiTeamMatch
+
+	apply := func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
+			}
+			return fmt.Errorf("failed to get multi-team match: %w", err)
+		}
+
+		var match models.MultiTeamMatch
+		if err := json.Unmarshal(data, &match); err != nil {
+			return fmt.Errorf("failed to unmarshal multi-team match: %w", err)
+		}
+		statuses, err := update(&match)
+		if err != nil {
+			return err
+		}
+		claims := newRequestClaims(statuses)
+		if len(claims.keys) > 0 {
+			if err := tx.Watch(ctx, claims.keys...).Err(); err != nil {
+				return fmt.Errorf("failed to watch match requests: %w", err)
+			}
+		}
+		if err := claims.load(ctx, tx, &match); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(&match); err != nil {
+			return fmt.Errorf("failed to marshal multi-team match: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, redis.KeepTTL)
+			claims.write(ctx, pipe, &match)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		updated = &match
+		return nil
+	}
+
+	for attempt := 0; attempt < maxCommitAttempts; attempt++ {
+		err := rs.client.Watch(ctx, apply, key)
+		if err == nil {
+			return updated, nil
+		}
+		if err != redis.TxFailedErr {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: concurrent update while backfilling match %s", ErrRequestClaimed, matchID)
+}
+
+// requestClaims holds the writes that move a match's requests out of the queue
+type requestClaims struct {
+	ids      []string
+	keys     []string
+	statuses map[string]*models.MatchStatusResponse
+	// Set by load
+	requestData [][]byte
+	requestTTLs []time.Duration
+	statusData  [][]byte
+}
+
+func newRequestClaims(statuses map[string]*models.MatchStatusResponse) *requestClaims {
+	claims := &requestClaims{statuses: statuses}
+	for requestID := range statuses {
+		claims.ids = append(claims.ids, requestID)
+		claims.keys = append(claims.keys, fmt.Sprintf("match_request:%s", requestID))
+	}
+	return claims
+}
+
+// load reads every request through tx, failing with ErrRequestClaimed if any is missing or no longer
+// pending, and prepares the claimed request and status data
+func (c *requestClaims) load(ctx context.Context, tx *redis.Tx, match *models.MultiTeamMatch) error {
+	c.requestData = make([][]byte, len(c.ids))
+	c.requestTTLs = make([]time.Duration, len(c.ids))
+	c.statusData = make([][]byte, len(c.ids))
+	for i, requestID := range c.ids {
+		data, err := tx.Get(ctx, c.keys[i]).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return fmt.Errorf("%w: %s not found", ErrRequestClaimed, requestID)
+			}
+			return fmt.Errorf("failed to get match request: %w", err)
+		}
+
+		var request models.MatchRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			return fmt.Errorf("failed to unmarshal match request: %w", err)
+		}
+		if request.Status != models.StatusPending {
+			return fmt.Errorf("%w: %s is %s", ErrRequestClaimed, requestID, request.Status)
+		}
+
+		request.Status = models.StatusMatched
+		if c.requestData[i], err = json.Marshal(request); err != nil {
+			return fmt.Errorf("failed to marshal match request: %w", err)
+		}
+		c.requestTTLs[i] = claimedRequestTTL(&request, match, time.Now())
+		if c.statusData[i], err = json.Marshal(c.statuses[requestID]); err != nil {
+			return fmt.Errorf("failed to marshal match status: %w", err)
+		}
+	}
+	return nil
+}
+
+// write queues the claim of every loaded request by match on pipe, and the match's ready check if it
+// awaits acceptance
+func (c *requestClaims) write(ctx context.Context, pipe redis.Pipeliner, match *models.MultiTeamMatch) {
+	queueKey := fmt.Sprintf("game_queue:%s", match.GameID)
+	for i, requestID := range c.ids {
+		pipe.Set(ctx, c.keys[i], c.requestData[i], c.requestTTLs[i])
+		pipe.LRem(ctx, queueKey, 0, requestID)
+		pipe.Set(ctx, fmt.Sprintf("request_match:%s", requestID), match.ID, matchTTL)
+		pipe.Set(ctx, fmt.Sprintf("match_status:%s", requestID), c.statusData[i], matchStatusTTL)
+	}
+	if match.Status == models.StatusAwaitingAccept && match.AcceptBy != nil {
+		pipe.ZAdd(ctx, fmt.Sprintf("ready_checks:%s", match.GameID), &redis.Z{
+			Score:  float64(match.AcceptBy.UnixMilli()),
+			Member: match.ID,
+		})
+	}
+}
+
+// ExpiredReadyChecks returns the game's ready checks whose deadline has passed, leaving them in the index
+func (rs *RedisStorage) ExpiredReadyChecks(ctx context.Context, gameID string, now time.Time) ([]string, error) {
+	deadline := strconv.FormatInt(now.UnixMilli(), 10)
+	matchIDs, err := rs.client.ZRangeByScore(ctx, fmt.Sprintf("ready_checks:%s", gameID), &redis.ZRangeBy{Min: "-inf", Max: deadline}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired ready checks: %w", err)
+	}
+	return matchIDs, nil
+}
+
+// RemoveReadyCheck removes a match from the game's ready check index
+func (rs *RedisStorage) RemoveReadyCheck(ctx context.Context, gameID, matchID string) error {
+	if err := rs.client.ZRem(ctx, fmt.Sprintf("ready_checks:%s", gameID), matchID).Err(); err != nil {
+		return fmt.Errorf("failed to remove ready check: %w", err)
+	}
+	return nil
+}
+
+// renewLockScript extends a lock only if it is still held by the caller
+var renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseLockScript deletes a lock only if it is still held by the caller
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AcquireGameLock takes the matchmaking lease for a game using SET NX PX
+func (rs *RedisStorage) AcquireGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("game_lock:%s", gameID)
+	acquired, err := rs.client.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire game lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// RenewGameLock extends the matchmaking lease for a game if owner still holds it
+func (rs *RedisStorage) RenewGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("game_lock:%s", gameID)
+	renewed, err := renewLockScript.Run(ctx, rs.client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew game lock: %w", err)
+	}
+	return renewed == 1, nil
+}
+
+// ReleaseGameLock gives up the matchmaking lease for a game if owner holds it
+func (rs *RedisStorage) ReleaseGameLock(ctx context.Context, gameID, owner string) error {
+	key := fmt.Sprintf("game_lock:%s", gameID)
+	if err := releaseLockScript.Run(ctx, rs.client, []string{key}, owner).Err(); err != nil {
+		return fmt.Errorf("failed to release game lock: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,14 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/mm-rules/matchmaking/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStoreAndGetMatchRequest(t *testing.T) {
@@ -30,7 +34,7 @@ func TestStoreAndGetGameConfig(t *testing.T) {
 	cfg := &models.GameConfig{
 		GameID: "g1",
 		Teams:  []models.Team{{Name: "red", Size: 2}},
-		Rules:  []models.Rule{{Field: "level", Min: intPtr(10), Strict: true, Priority: 1}},
+		Rules:  []models.Rule{{Field: "level", Min: floatPtr(10), Strict: true, Priority: 1}},
 		UpdatedAt: time.Now(),
 	}
 	_ = storage
@@ -38,4 +42,79 @@ func TestStoreAndGetGameConfig(t *testing.T) {
 	_ = cfg
 }
 
-func intPtr(i int) *int { return &i } 
\ No newline at end of file
+func TestRedisStorage_RebuildIndexes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rs := NewRedisStorage(mr.Addr(), "", 0)
+	defer rs.Close()
+	ctx := context.Background()
+
+	// Data written before the index sets existed
+	require.NoError(t, mr.Set("game_config:g1", `{"game_id":"g1"}`))
+	require.NoError(t, mr.Set("game_config:g2", `{"game_id":"g2"}`))
+	_, err := mr.Lpush("game_queue:g1", "r1")
+	require.NoError(t, err)
+	_, err = mr.Lpush("game_queue:g3", "r2")
+	require.NoError(t, err)
+
+	gameIDs, err := rs.GetGameIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, gameIDs)
+
+	require.NoError(t, rs.RebuildIndexes(ctx))
+
+	gameIDs, err = rs.GetGameIDs(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"g1", "g2"}, gameIDs)
+
+	stats, err := rs.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats["total_game_configs"])
+	assert.Equal(t, 2, stats["total_game_queues"])
+	assert.Equal(t, 2, stats["total_pending_requests"])
+}
+
+func TestRedisStorage_GetGameQueueBatches(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rs := NewRedisStorage(mr.Addr(), "", 0)
+	defer rs.Close()
+	ctx := context.Background()
+
+	// More requests than fit in one MGET batch, with every third one expired
+	total := queueBatchSize*2 + 7
+	for i := 0; i < total; i++ {
+		request := models.NewMatchRequest(fmt.Sprintf("p%d", i), "g1", nil)
+		require.NoError(t, rs.StoreMatchRequest(ctx, request))
+		if i%3 == 0 {
+			mr.Del(fmt.Sprintf("match_request:%s", request.ID))
+		}
+	}
+	expired := (total + 2) / 3
+
+	queue, err := rs.GetGameQueue(ctx, "g1")
+	require.NoError(t, err)
+	assert.Len(t, queue, total-expired)
+
+	length, err := rs.client.LLen(ctx, "game_queue:g1").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(total-expired), length)
+}
+
+func BenchmarkRedisStorage_GetGameQueue(b *testing.B) {
+	mr := miniredis.RunT(b)
+	rs := NewRedisStorage(mr.Addr(), "", 0)
+	defer rs.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 5000; i++ {
+		_ = rs.StoreMatchRequest(ctx, models.NewMatchRequest(fmt.Sprintf("p%d", i), "g1", nil))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := rs.GetGameQueue(ctx, "g1"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func floatPtr(f float64) *float64 { return &f } 
\ No newline at end of file
@@ -2,15 +2,63 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/mm-rules/matchmaking/internal/models"
 )
 
+// Expiry applied to stored records; every Storage implementation uses the same values
+const (
+	matchRequestTTL = 60 * time.Second   // match requests that don't carry their own TTL
+	matchStatusTTL  = time.Hour          // cached match status responses
+	matchTTL        = 7 * 24 * time.Hour // matches and request -> match mappings
+)
+
+// RequestTTL returns how long a match request lives without a heartbeat
+func RequestTTL(request *models.MatchRequest) time.Duration {
+	if request.TTL > 0 {
+		return time.Duration(request.TTL) * time.Second
+	}
+	return matchRequestTTL
+}
+
+// claimedRequestTTL returns how long a request claimed by match lives. While the match awaits
+// acceptance the request is kept for its full TTL past the accept deadline, so a failed ready
+// check can still put it back in the queue.
+func claimedRequestTTL(request *models.MatchRequest, match *models.MultiTeamMatch, now time.Time) time.Duration {
+	ttl := RequestTTL(request)
+	if match.Status == models.StatusAwaitingAccept && match.AcceptBy != nil && match.AcceptBy.After(now) {
+		ttl += match.AcceptBy.Sub(now)
+	}
+	return ttl
+}
+
+var (
+	// ErrRequestNotFound is returned when a match request does not exist or has expired
+	ErrRequestNotFound = errors.New("match request not found")
+	// ErrRequestClaimed is returned by CommitMatch when a request in the match is missing or no longer pending,
+	// and by CancelMatchRequest when the request has already been matched
+	ErrRequestClaimed = errors.New("match request already claimed")
+	// ErrMatchNotFound is returned when a multi-team match does not exist or has expired
+	ErrMatchNotFound = errors.New("match not found")
+	// ErrConcurrentUpdate is returned when a record kept changing while it was being updated; the
+	// update was not applied and may be retried
+	ErrConcurrentUpdate = errors.New("concurrent update")
+)
+
 type Storage interface {
 	StoreGameConfig(ctx context.Context, config *models.GameConfig) error
 	GetGameConfig(ctx context.Context, gameID string) (*models.GameConfig, error)
+	GetGameIDs(ctx context.Context) ([]string, error)
 	StoreMatchRequest(ctx context.Context, request *models.MatchRequest) error
 	GetMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, error)
+	// RefreshMatchRequest resets a match request's expiry to its TTL, keeping the ticket alive
+	RefreshMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, error)
+	// CancelMatchRequest marks a pending match request cancelled, removes it from its game queue and
+	// stores a cancelled status response. It reports whether it changed the request's status:
+	// cancelling an already cancelled request is a no-op and reports false.
+	CancelMatchRequest(ctx context.Context, requestID string) (*models.MatchRequest, bool, error)
 	GetGameQueue(ctx context.Context, gameID string) ([]*models.MatchRequest, error)
 	GetMatchStatus(ctx context.Context, requestID string) (*models.MatchStatusResponse, error)
 	StoreMatch(ctx context.Context, match *models.Match) error
@@ -25,4 +73,34 @@ type Storage interface {
 	CleanupExpiredRequests(ctx context.Context) error
 	StoreMultiTeamMatch(ctx context.Context, match *models.MultiTeamMatch) error
 	GetMultiTeamMatch(ctx context.Context, matchID string) (*models.MultiTeamMatch, error)
+	// UpdateMultiTeamMatch applies update to a stored match and saves the result atomically, keeping the
+	// match's expiry. update may run more than once if the match changes concurrently; if it returns an
+	// error nothing is written and that error is returned.
+	UpdateMultiTeamMatch(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) error) (*models.MultiTeamMatch, error)
+	// RequeueMatchRequest puts a claimed request back in its game queue as pending, keeping its
+	// CreatedAt, and clears its match status and request -> match mapping
+	RequeueMatchRequest(ctx context.Context, request *models.MatchRequest) error
+	// CommitMatch stores a match and, for every request ID in statuses, marks the request matched,
+	// removes it from the game queue and stores its request -> match mapping and status response.
+	// All writes happen atomically; if any request is already claimed nothing is written. A match
+	// awaiting acceptance is added to its game's ready checks until its accept deadline.
+	CommitMatch(ctx context.Context, match *models.MultiTeamMatch, statuses map[string]*models.MatchStatusResponse) error
+	// CommitBackfill applies update to a stored match and, in the same atomic write, claims the requests
+	// in the statuses update returns as CommitMatch does. The match keeps its expiry. update may run more
+	// than once if the match or a request changes concurrently; if update returns an error, or a request
+	// is already claimed, nothing is written.
+	CommitBackfill(ctx context.Context, matchID string, update func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error)) (*models.MultiTeamMatch, error)
+	// ExpiredReadyChecks returns the IDs of the game's matches whose accept deadline is at or before now,
+	// earliest deadline first. A match stays listed, whether or not it was accepted in time, until
+	// RemoveReadyCheck takes it out, so one that fails to expire is returned again.
+	ExpiredReadyChecks(ctx context.Context, gameID string, now time.Time) ([]string, error)
+	// RemoveReadyCheck takes a match out of its game's ready checks; it is a no-op for an unlisted match
+	RemoveReadyCheck(ctx context.Context, gameID, matchID string) error
+	// AcquireGameLock takes the matchmaking lease for a game for ttl. It reports false if any
+	// owner, including owner itself, holds an unexpired lease; use RenewGameLock to extend one.
+	AcquireGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error)
+	// RenewGameLock extends a lease held by owner, reporting false if owner no longer holds it
+	RenewGameLock(ctx context.Context, gameID, owner string, ttl time.Duration) (bool, error)
+	// ReleaseGameLock gives up a lease held by owner; it is a no-op if owner does not hold it
+	ReleaseGameLock(ctx context.Context, gameID, owner string) error
 }
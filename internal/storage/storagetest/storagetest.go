@@ -0,0 +1,952 @@
+// Package storagetest provides a conformance test suite for storage.Storage implementations.
+// Every backend should behave exactly like RedisStorage when run through Run.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mm-rules/matchmaking/internal/models"
+	"github.com/mm-rules/matchmaking/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend is a storage implementation under test
+type Backend struct {
+	// Storage is an empty Storage instance
+	Storage storage.Storage
+	// FastForward advances the backend's clock by d so records expire as they would in real time
+	FastForward func(d time.Duration)
+}
+
+// NewBackendFunc creates a fresh, empty backend for a single test
+type NewBackendFunc func(t *testing.T) Backend
+
+// Run runs the full conformance suite against the backends produced by newBackend
+func Run(t *testing.T, newBackend NewBackendFunc) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, b Backend)
+	}{
+		{"Ping", testPing},
+		{"GameConfigRoundTrip", testGameConfigRoundTrip},
+		{"GameIDs", testGameIDs},
+		{"MatchRequestRoundTrip", testMatchRequestRoundTrip},
+		{"MatchRequestNotFound", testMatchRequestNotFound},
+		{"QueueOrdering", testQueueOrdering},
+		{"QueuesArePerGame", testQueuesArePerGame},
+		{"RemoveFromQueue", testRemoveFromQueue},
+		{"StatusTransitions", testStatusTransitions},
+		{"MatchStatusRoundTrip", testMatchStatusRoundTrip},
+		{"RequestMatchMapping", testRequestMatchMapping},
+		{"MatchRoundTrip", testMatchRoundTrip},
+		{"MultiTeamMatchRoundTrip", testMultiTeamMatchRoundTrip},
+		{"UpdateMultiTeamMatch", testUpdateMultiTeamMatch},
+		{"UpdateMultiTeamMatchError", testUpdateMultiTeamMatchError},
+		{"CleanupExpiredRequests", testCleanupExpiredRequests},
+		{"GetGameQueuePrunesExpired", testGetGameQueuePrunesExpired},
+		{"TTLExpiry", testTTLExpiry},
+		{"RequestTTL", testRequestTTL},
+		{"RefreshMatchRequest", testRefreshMatchRequest},
+		{"RefreshMatchRequestNotFound", testRefreshMatchRequestNotFound},
+		{"Stats", testStats},
+		{"CommitMatch", testCommitMatch},
+		{"CommitMatchAlreadyClaimed", testCommitMatchAlreadyClaimed},
+		{"CommitMatchMissingRequest", testCommitMatchMissingRequest},
+		{"CommitMatchConcurrent", testCommitMatchConcurrent},
+		{"CommitBackfill", testCommitBackfill},
+		{"CommitBackfillAlreadyClaimed", testCommitBackfillAlreadyClaimed},
+		{"CommitBackfillConcurrentUpdate", testCommitBackfillConcurrentUpdate},
+		{"CancelMatchRequest", testCancelMatchRequest},
+		{"CancelMatchRequestMatched", testCancelMatchRequestMatched},
+		{"CancelMatchRequestNotFound", testCancelMatchRequestNotFound},
+		{"RequeueMatchRequest", testRequeueMatchRequest},
+		{"ReadyChecks", testReadyChecks},
+		{"GameLockExclusive", testGameLockExclusive},
+		{"GameLockRenewAndRelease", testGameLockRenewAndRelease},
+		{"GameLockExpiry", testGameLockExpiry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newBackend(t))
+		})
+	}
+}
+
+func newRequest(id, playerID, gameID string, createdAt time.Time) *models.MatchRequest {
+	return &models.MatchRequest{
+		ID:        id,
+		PlayerID:  playerID,
+		GameID:    gameID,
+		Metadata:  map[string]interface{}{"level": 25, "region": "us-west"},
+		CreatedAt: createdAt.UTC().Truncate(time.Second),
+		Status:    models.StatusPending,
+	}
+}
+
+func newGameConfig(gameID string) *models.GameConfig {
+	min := 10.0
+	return &models.GameConfig{
+		GameID: gameID,
+		Teams:  []models.Team{{Name: "red", Size: 2}, {Name: "blue", Size: 2}},
+		Rules:  []models.Rule{{Field: "level", Min: &min, Strict: true, Priority: 1}},
+	}
+}
+
+func queueIDs(t *testing.T, s storage.Storage, gameID string) []string {
+	queue, err := s.GetGameQueue(context.Background(), gameID)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(queue))
+	for _, r := range queue {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func testPing(t *testing.T, b Backend) {
+	assert.NoError(t, b.Storage.Ping(context.Background()))
+}
+
+func testGameConfigRoundTrip(t *testing.T, b Backend) {
+	ctx := context.Background()
+	cfg := newGameConfig("g1")
+
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, cfg))
+	assert.False(t, cfg.UpdatedAt.IsZero(), "StoreGameConfig should set UpdatedAt")
+
+	got, err := b.Storage.GetGameConfig(ctx, "g1")
+	require.NoError(t, err)
+	assert.Equal(t, cfg.GameID, got.GameID)
+	assert.Equal(t, cfg.Teams, got.Teams)
+	require.Len(t, got.Rules, 1)
+	assert.Equal(t, "level", got.Rules[0].Field)
+	assert.Equal(t, 10.0, *got.Rules[0].Min)
+
+	_, err = b.Storage.GetGameConfig(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func testGameIDs(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	gameIDs, err := b.Storage.GetGameIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, gameIDs)
+
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, newGameConfig("g1")))
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, newGameConfig("g2")))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g3", time.Now())))
+
+	gameIDs, err = b.Storage.GetGameIDs(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"g1", "g2"}, gameIDs)
+}
+
+func testMatchRequestRoundTrip(t *testing.T, b Backend) {
+	ctx := context.Background()
+	request := newRequest("r1", "p1", "g1", time.Now())
+
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, request))
+
+	got, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, request.ID, got.ID)
+	assert.Equal(t, request.PlayerID, got.PlayerID)
+	assert.Equal(t, request.GameID, got.GameID)
+	assert.Equal(t, models.StatusPending, got.Status)
+	assert.True(t, request.CreatedAt.Equal(got.CreatedAt))
+	assert.Equal(t, float64(25), got.Metadata["level"])
+	assert.Equal(t, "us-west", got.Metadata["region"])
+}
+
+func testMatchRequestNotFound(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	_, err := b.Storage.GetMatchRequest(ctx, "missing")
+	assert.Error(t, err)
+
+	assert.Error(t, b.Storage.UpdateMatchRequestStatus(ctx, "missing", models.StatusMatched))
+}
+
+func testQueueOrdering(t *testing.T, b Backend) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", now.Add(time.Duration(i)*time.Second))))
+	}
+
+	// Newest requests are at the head of the queue
+	assert.Equal(t, []string{"r3", "r2", "r1"}, queueIDs(t, b.Storage, "g1"))
+}
+
+func testQueuesArePerGame(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r2", "p2", "g2", time.Now())))
+
+	assert.Equal(t, []string{"r1"}, queueIDs(t, b.Storage, "g1"))
+	assert.Equal(t, []string{"r2"}, queueIDs(t, b.Storage, "g2"))
+	assert.Empty(t, queueIDs(t, b.Storage, "g3"))
+}
+
+func testRemoveFromQueue(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+
+	require.NoError(t, b.Storage.RemoveFromQueue(ctx, "g1", "r2"))
+	assert.Equal(t, []string{"r3", "r1"}, queueIDs(t, b.Storage, "g1"))
+
+	// Removing an unknown request is not an error
+	require.NoError(t, b.Storage.RemoveFromQueue(ctx, "g1", "missing"))
+	assert.Equal(t, []string{"r3", "r1"}, queueIDs(t, b.Storage, "g1"))
+
+	// The request itself is still readable after leaving the queue
+	_, err := b.Storage.GetMatchRequest(ctx, "r2")
+	assert.NoError(t, err)
+}
+
+func testStatusTransitions(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+
+	for _, status := range []models.MatchStatus{models.StatusMatched, models.StatusAllocated, models.StatusFailed} {
+		require.NoError(t, b.Storage.UpdateMatchRequestStatus(ctx, "r1", status))
+
+		got, err := b.Storage.GetMatchRequest(ctx, "r1")
+		require.NoError(t, err)
+		assert.Equal(t, status, got.Status)
+	}
+
+	// Status updates never add the request to the queue a second time
+	assert.Equal(t, []string{"r1"}, queueIDs(t, b.Storage, "g1"))
+}
+
+func testMatchStatusRoundTrip(t *testing.T, b Backend) {
+	ctx := context.Background()
+	team := "red"
+	status := &models.MatchStatusResponse{
+		Status:     models.StatusMatched,
+		Team:       &team,
+		MatchID:    "m1",
+		Players:    []string{"p1", "p2"},
+		TeamName:   "red",
+		AllPlayers: []string{"p1", "p2", "p3", "p4"},
+		Session:    &models.GameSession{IP: "10.0.0.1", Port: 7777, ID: "s1"},
+	}
+
+	require.NoError(t, b.Storage.StoreMatchStatus(ctx, "r1", status))
+
+	got, err := b.Storage.GetMatchStatus(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, status, got)
+
+	_, err = b.Storage.GetMatchStatus(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func testRequestMatchMapping(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	require.NoError(t, b.Storage.StoreRequestMatchMapping(ctx, "r1", "m1"))
+
+	matchID, err := b.Storage.GetMatchIDForRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, "m1", matchID)
+
+	_, err = b.Storage.GetMatchIDForRequest(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func testMatchRoundTrip(t *testing.T, b Backend) {
+	ctx := context.Background()
+	match := &models.Match{
+		ID:        "m1",
+		GameID:    "g1",
+		TeamName:  "red",
+		Players:   []string{"p1", "p2"},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Session:   &models.GameSession{IP: "10.0.0.1", Port: 7777, ID: "s1"},
+	}
+
+	require.NoError(t, b.Storage.StoreMatch(ctx, match))
+
+	got, err := b.Storage.GetMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, match.ID, got.ID)
+	assert.Equal(t, match.Players, got.Players)
+	assert.Equal(t, match.Session, got.Session)
+	assert.True(t, match.CreatedAt.Equal(got.CreatedAt))
+
+	_, err = b.Storage.GetMatch(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func testMultiTeamMatchRoundTrip(t *testing.T, b Backend) {
+	ctx := context.Background()
+	match := &models.MultiTeamMatch{
+		ID:     "m1",
+		GameID: "g1",
+		Teams: map[string][]string{
+			"red":  {"p1", "p2"},
+			"blue": {"p3", "p4"},
+		},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	require.NoError(t, b.Storage.StoreMultiTeamMatch(ctx, match))
+
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, match.ID, got.ID)
+	assert.Equal(t, match.GameID, got.GameID)
+	assert.Equal(t, match.Teams, got.Teams)
+	assert.True(t, match.CreatedAt.Equal(got.CreatedAt))
+
+	_, err = b.Storage.GetMultiTeamMatch(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrMatchNotFound)
+}
+
+func testUpdateMultiTeamMatch(t *testing.T, b Backend) {
+	ctx := context.Background()
+	match, _ := newCommit("m1", "g1", "r1", "r2")
+	match.Status = models.StatusAwaitingAccept
+	require.NoError(t, b.Storage.StoreMultiTeamMatch(ctx, match))
+
+	updated, err := b.Storage.UpdateMultiTeamMatch(ctx, "m1", func(match *models.MultiTeamMatch) error {
+		match.Accepted = append(match.Accepted, "pr1")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr1"}, updated.Accepted)
+
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr1"}, got.Accepted)
+	assert.Equal(t, models.StatusAwaitingAccept, got.Status)
+	assert.Equal(t, match.Teams, got.Teams)
+
+	_, err = b.Storage.UpdateMultiTeamMatch(ctx, "missing", func(match *models.MultiTeamMatch) error { return nil })
+	assert.ErrorIs(t, err, storage.ErrMatchNotFound)
+}
+
+func testUpdateMultiTeamMatchError(t *testing.T, b Backend) {
+	ctx := context.Background()
+	match, _ := newCommit("m1", "g1", "r1")
+	require.NoError(t, b.Storage.StoreMultiTeamMatch(ctx, match))
+
+	errRejected := errors.New("rejected")
+	_, err := b.Storage.UpdateMultiTeamMatch(ctx, "m1", func(match *models.MultiTeamMatch) error {
+		match.Accepted = []string{"pr1"}
+		return errRejected
+	})
+	assert.ErrorIs(t, err, errRejected)
+
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Empty(t, got.Accepted)
+}
+
+func testCleanupExpiredRequests(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, newGameConfig("g1")))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+
+	b.FastForward(30 * time.Second)
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r2", "p2", "g1", time.Now())))
+
+	// Nothing has expired yet
+	require.NoError(t, b.Storage.CleanupExpiredRequests(ctx))
+	assert.Equal(t, []string{"r2", "r1"}, queueIDs(t, b.Storage, "g1"))
+
+	// r1 expires, r2 is still alive
+	b.FastForward(31 * time.Second)
+	require.NoError(t, b.Storage.CleanupExpiredRequests(ctx))
+	assert.Equal(t, []string{"r2"}, queueIDs(t, b.Storage, "g1"))
+
+	stats, err := b.Storage.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats["total_pending_requests"])
+
+	// Game configs never expire
+	_, err = b.Storage.GetGameConfig(ctx, "g1")
+	assert.NoError(t, err)
+}
+
+func testGetGameQueuePrunesExpired(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+	b.FastForward(30 * time.Second)
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r2", "p2", "g1", time.Now())))
+	b.FastForward(31 * time.Second)
+
+	// Reading the queue drops r1 from it without a separate cleanup pass
+	assert.Equal(t, []string{"r2"}, queueIDs(t, b.Storage, "g1"))
+
+	stats, err := b.Storage.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats["total_pending_requests"])
+}
+
+func testTTLExpiry(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, newGameConfig("g1")))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+	require.NoError(t, b.Storage.StoreMatchStatus(ctx, "r1", &models.MatchStatusResponse{Status: models.StatusMatched}))
+	require.NoError(t, b.Storage.StoreRequestMatchMapping(ctx, "r1", "m1"))
+	require.NoError(t, b.Storage.StoreMultiTeamMatch(ctx, &models.MultiTeamMatch{ID: "m1", GameID: "g1"}))
+	require.NoError(t, b.Storage.StoreMatch(ctx, &models.Match{ID: "m1", GameID: "g1"}))
+
+	// Updating the status refreshes the request expiry
+	b.FastForward(45 * time.Second)
+	require.NoError(t, b.Storage.UpdateMatchRequestStatus(ctx, "r1", models.StatusMatched))
+	b.FastForward(45 * time.Second)
+	_, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+
+	// Match requests expire after 60 seconds
+	b.FastForward(15 * time.Second)
+	_, err = b.Storage.GetMatchRequest(ctx, "r1")
+	assert.Error(t, err)
+	assert.Empty(t, queueIDs(t, b.Storage, "g1"))
+
+	// Match statuses expire after an hour
+	_, err = b.Storage.GetMatchStatus(ctx, "r1")
+	require.NoError(t, err)
+	b.FastForward(time.Hour)
+	_, err = b.Storage.GetMatchStatus(ctx, "r1")
+	assert.Error(t, err)
+
+	// Matches and mappings expire after seven days
+	_, err = b.Storage.GetMatchIDForRequest(ctx, "r1")
+	require.NoError(t, err)
+	b.FastForward(7 * 24 * time.Hour)
+	_, err = b.Storage.GetMatchIDForRequest(ctx, "r1")
+	assert.Error(t, err)
+	_, err = b.Storage.GetMultiTeamMatch(ctx, "m1")
+	assert.Error(t, err)
+	_, err = b.Storage.GetMatch(ctx, "m1")
+	assert.Error(t, err)
+
+	// Game configs never expire
+	_, err = b.Storage.GetGameConfig(ctx, "g1")
+	assert.NoError(t, err)
+}
+
+func testRequestTTL(t *testing.T, b Backend) {
+	ctx := context.Background()
+	request := newRequest("r1", "p1", "g1", time.Now())
+	request.TTL = 300
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, request))
+
+	// A request with its own TTL outlives the default
+	b.FastForward(299 * time.Second)
+	got, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, 300, got.TTL)
+
+	b.FastForward(time.Second)
+	_, err = b.Storage.GetMatchRequest(ctx, "r1")
+	assert.Error(t, err)
+	assert.Empty(t, queueIDs(t, b.Storage, "g1"))
+}
+
+func testRefreshMatchRequest(t *testing.T, b Backend) {
+	ctx := context.Background()
+	request := newRequest("r1", "p1", "g1", time.Now())
+	request.TTL = 30
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, request))
+
+	// Each heartbeat restarts the request's TTL
+	for i := 0; i < 3; i++ {
+		b.FastForward(20 * time.Second)
+		got, err := b.Storage.RefreshMatchRequest(ctx, "r1")
+		require.NoError(t, err)
+		assert.Equal(t, "r1", got.ID)
+	}
+	assert.Equal(t, []string{"r1"}, queueIDs(t, b.Storage, "g1"))
+
+	b.FastForward(30 * time.Second)
+	_, err := b.Storage.GetMatchRequest(ctx, "r1")
+	assert.Error(t, err)
+}
+
+func testRefreshMatchRequestNotFound(t *testing.T, b Backend) {
+	ctx := context.Background()
+	_, err := b.Storage.RefreshMatchRequest(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrRequestNotFound)
+
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+	b.FastForward(time.Minute)
+	_, err = b.Storage.RefreshMatchRequest(ctx, "r1")
+	assert.Error(t, err)
+}
+
+func testStats(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, newGameConfig("g1")))
+	require.NoError(t, b.Storage.StoreGameConfig(ctx, newGameConfig("g2")))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r2", "p2", "g1", time.Now())))
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r3", "p3", "g2", time.Now())))
+
+	stats, err := b.Storage.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats["total_game_configs"])
+	assert.Equal(t, 2, stats["total_game_queues"])
+	assert.Equal(t, 3, stats["total_pending_requests"])
+
+	require.NoError(t, b.Storage.RemoveFromQueue(ctx, "g2", "r3"))
+	stats, err = b.Storage.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats["total_game_queues"])
+	assert.Equal(t, 2, stats["total_pending_requests"])
+}
+
+func newCommit(matchID, gameID string, requestIDs ...string) (*models.MultiTeamMatch, map[string]*models.MatchStatusResponse) {
+	match := &models.MultiTeamMatch{
+		ID:        matchID,
+		GameID:    gameID,
+		Teams:     map[string][]string{"red": {}},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	statuses := make(map[string]*models.MatchStatusResponse, len(requestIDs))
+	for _, requestID := range requestIDs {
+		match.Teams["red"] = append(match.Teams["red"], "p"+requestID)
+		statuses[requestID] = &models.MatchStatusResponse{
+			Status:   models.StatusMatched,
+			MatchID:  matchID,
+			TeamName: "red",
+		}
+	}
+	return match, statuses
+}
+
+func testCommitMatch(t *testing.T, b Backend) {
+	ctx := context.Background()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+
+	match, statuses := newCommit("m1", "g1", "r1", "r2")
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, match.Teams, got.Teams)
+
+	for _, id := range []string{"r1", "r2"} {
+		request, err := b.Storage.GetMatchRequest(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusMatched, request.Status)
+
+		matchID, err := b.Storage.GetMatchIDForRequest(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "m1", matchID)
+
+		status, err := b.Storage.GetMatchStatus(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, statuses[id], status)
+	}
+
+	// Only the unmatched request is left in the queue
+	assert.Equal(t, []string{"r3"}, queueIDs(t, b.Storage, "g1"))
+}
+
+func testCommitMatchAlreadyClaimed(t *testing.T, b Backend) {
+	ctx := context.Background()
+	for _, id := range []string{"r1", "r2"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+	require.NoError(t, b.Storage.UpdateMatchRequestStatus(ctx, "r2", models.StatusMatched))
+
+	match, statuses := newCommit("m1", "g1", "r1", "r2")
+	err := b.Storage.CommitMatch(ctx, match, statuses)
+	assert.ErrorIs(t, err, storage.ErrRequestClaimed)
+
+	// Nothing was written
+	_, err = b.Storage.GetMultiTeamMatch(ctx, "m1")
+	assert.Error(t, err)
+	request, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, request.Status)
+	_, err = b.Storage.GetMatchIDForRequest(ctx, "r1")
+	assert.Error(t, err)
+	_, err = b.Storage.GetMatchStatus(ctx, "r1")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"r2", "r1"}, queueIDs(t, b.Storage, "g1"))
+}
+
+func testCommitMatchMissingRequest(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+
+	match, statuses := newCommit("m1", "g1", "r1", "missing")
+	err := b.Storage.CommitMatch(ctx, match, statuses)
+	assert.ErrorIs(t, err, storage.ErrRequestClaimed)
+
+	_, err = b.Storage.GetMultiTeamMatch(ctx, "m1")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"r1"}, queueIDs(t, b.Storage, "g1"))
+}
+
+func testCommitMatchConcurrent(t *testing.T, b Backend) {
+	ctx := context.Background()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+
+	// Two replicas race to put r2 into different matches; exactly one may win
+	const attempts = 8
+	var wg sync.WaitGroup
+	errs := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			other := "r1"
+			if i%2 == 1 {
+				other = "r3"
+			}
+			match, statuses := newCommit(fmt.Sprintf("m%d", i), "g1", other, "r2")
+			errs[i] = b.Storage.CommitMatch(ctx, match, statuses)
+		}(i)
+	}
+	wg.Wait()
+
+	committed := 0
+	for _, err := range errs {
+		if err == nil {
+			committed++
+			continue
+		}
+		assert.True(t, errors.Is(err, storage.ErrRequestClaimed), "unexpected error: %v", err)
+	}
+	assert.Equal(t, 1, committed)
+	assert.Len(t, queueIDs(t, b.Storage, "g1"), 1)
+}
+
+// backfill returns a CommitBackfill update that adds the players of requestIDs to the red team
+func backfill(requestIDs ...string) func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error) {
+	return func(match *models.MultiTeamMatch) (map[string]*models.MatchStatusResponse, error) {
+		statuses := make(map[string]*models.MatchStatusResponse, len(requestIDs))
+		for _, requestID := range requestIDs {
+			match.Teams["red"] = append(match.Teams["red"], "p"+requestID)
+			match.RequestIDs = append(match.RequestIDs, requestID)
+			statuses[requestID] = &models.MatchStatusResponse{
+				Status:   models.StatusMatched,
+				MatchID:  match.ID,
+				TeamName: "red",
+				Session:  match.Session,
+			}
+		}
+		return statuses, nil
+	}
+}
+
+func testCommitBackfill(t *testing.T, b Backend) {
+	ctx := context.Background()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+	match, statuses := newCommit("m1", "g1", "r1")
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+
+	updated, err := b.Storage.CommitBackfill(ctx, "m1", backfill("r2"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr1", "pr2"}, updated.Teams["red"])
+
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr1", "pr2"}, got.Teams["red"])
+	assert.Equal(t, []string{"r2"}, got.RequestIDs)
+
+	request, err := b.Storage.GetMatchRequest(ctx, "r2")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusMatched, request.Status)
+	matchID, err := b.Storage.GetMatchIDForRequest(ctx, "r2")
+	require.NoError(t, err)
+	assert.Equal(t, "m1", matchID)
+	status, err := b.Storage.GetMatchStatus(ctx, "r2")
+	require.NoError(t, err)
+	assert.Equal(t, "m1", status.MatchID)
+	assert.Equal(t, []string{"r3"}, queueIDs(t, b.Storage, "g1"))
+
+	_, err = b.Storage.CommitBackfill(ctx, "missing", backfill("r3"))
+	assert.ErrorIs(t, err, storage.ErrMatchNotFound)
+}
+
+func testCommitBackfillAlreadyClaimed(t *testing.T, b Backend) {
+	ctx := context.Background()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+	match, statuses := newCommit("m1", "g1", "r1")
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+	require.NoError(t, b.Storage.UpdateMatchRequestStatus(ctx, "r3", models.StatusMatched))
+
+	_, err := b.Storage.CommitBackfill(ctx, "m1", backfill("r2", "r3"))
+	assert.ErrorIs(t, err, storage.ErrRequestClaimed)
+
+	// Nothing was written
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pr1"}, got.Teams["red"])
+	request, err := b.Storage.GetMatchRequest(ctx, "r2")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, request.Status)
+	_, err = b.Storage.GetMatchStatus(ctx, "r2")
+	assert.Error(t, err)
+}
+
+func testCommitBackfillConcurrentUpdate(t *testing.T, b Backend) {
+	ctx := context.Background()
+	const players = 4
+	var requestIDs []string
+	for i := 0; i < players; i++ {
+		id := fmt.Sprintf("r%d", i)
+		requestIDs = append(requestIDs, id)
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+	match, _ := newCommit("m1", "g1")
+	require.NoError(t, b.Storage.StoreMultiTeamMatch(ctx, match))
+
+	// Backfills race a session being recorded on the match; no write may be lost
+	session := &models.GameSession{IP: "10.0.0.1", Port: 7777, ID: "session1"}
+	var wg sync.WaitGroup
+	errs := make([]error, players+1)
+	for i, requestID := range requestIDs {
+		wg.Add(1)
+		go func(i int, requestID string) {
+			defer wg.Done()
+			_, errs[i] = b.Storage.CommitBackfill(ctx, "m1", backfill(requestID))
+		}(i, requestID)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, errs[players] = b.Storage.UpdateMultiTeamMatch(ctx, "m1", func(match *models.MultiTeamMatch) error {
+			match.Session = session
+			return nil
+		})
+	}()
+	wg.Wait()
+
+	got, err := b.Storage.GetMultiTeamMatch(ctx, "m1")
+	require.NoError(t, err)
+	var added []string
+	for i, err := range errs {
+		if err != nil {
+			continue // Retries can run out under contention, but a failed write must leave no trace
+		}
+		if i < players {
+			added = append(added, "p"+requestIDs[i])
+		} else {
+			assert.Equal(t, session, got.Session)
+		}
+	}
+	assert.NotEmpty(t, added)
+	assert.ElementsMatch(t, added, got.Teams["red"])
+}
+
+func testCancelMatchRequest(t *testing.T, b Backend) {
+	ctx := context.Background()
+	for _, id := range []string{"r1", "r2"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", time.Now())))
+	}
+
+	request, cancelled, err := b.Storage.CancelMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.True(t, cancelled)
+	assert.Equal(t, models.StatusCancelled, request.Status)
+	assert.Equal(t, "g1", request.GameID)
+
+	assert.Equal(t, []string{"r2"}, queueIDs(t, b.Storage, "g1"))
+	got, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, got.Status)
+	status, err := b.Storage.GetMatchStatus(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, status.Status)
+
+	// Cancelling again is a no-op
+	request, cancelled, err = b.Storage.CancelMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.False(t, cancelled)
+	assert.Equal(t, models.StatusCancelled, request.Status)
+
+	// A cancelled request can no longer be matched
+	match, statuses := newCommit("m1", "g1", "r1", "r2")
+	assert.ErrorIs(t, b.Storage.CommitMatch(ctx, match, statuses), storage.ErrRequestClaimed)
+}
+
+func testCancelMatchRequestMatched(t *testing.T, b Backend) {
+	ctx := context.Background()
+	require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest("r1", "p1", "g1", time.Now())))
+	match, statuses := newCommit("m1", "g1", "r1")
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+
+	_, _, err := b.Storage.CancelMatchRequest(ctx, "r1")
+	assert.ErrorIs(t, err, storage.ErrRequestClaimed)
+
+	status, err := b.Storage.GetMatchStatus(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusMatched, status.Status)
+}
+
+func testCancelMatchRequestNotFound(t *testing.T, b Backend) {
+	_, _, err := b.Storage.CancelMatchRequest(context.Background(), "missing")
+	assert.ErrorIs(t, err, storage.ErrRequestNotFound)
+}
+
+func testRequeueMatchRequest(t *testing.T, b Backend) {
+	ctx := context.Background()
+	createdAt := time.Now().Add(-time.Minute)
+	for _, id := range []string{"r1", "r2"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", createdAt)))
+	}
+	match, statuses := newCommit("m1", "g1", "r1", "r2")
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+
+	request, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	require.NoError(t, b.Storage.RequeueMatchRequest(ctx, request))
+
+	assert.Equal(t, []string{"r1"}, queueIDs(t, b.Storage, "g1"))
+	got, err := b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, got.Status)
+	assert.True(t, request.CreatedAt.Equal(got.CreatedAt))
+	_, err = b.Storage.GetMatchStatus(ctx, "r1")
+	assert.Error(t, err)
+	_, err = b.Storage.GetMatchIDForRequest(ctx, "r1")
+	assert.Error(t, err)
+
+	// The requeued request can be matched again
+	match, statuses = newCommit("m2", "g1", "r1")
+	assert.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+}
+
+func testReadyChecks(t *testing.T, b Backend) {
+	ctx := context.Background()
+	now := time.Now()
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, b.Storage.StoreMatchRequest(ctx, newRequest(id, "p"+id, "g1", now)))
+	}
+
+	acceptBy := now.Add(30 * time.Second)
+	match, statuses := newCommit("m1", "g1", "r1", "r2")
+	match.Status = models.StatusAwaitingAccept
+	match.AcceptBy = &acceptBy
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+	match, statuses = newCommit("m2", "g1", "r3")
+	require.NoError(t, b.Storage.CommitMatch(ctx, match, statuses))
+
+	// Only matches awaiting acceptance are returned, once their deadline passes, until they are removed
+	expired, err := b.Storage.ExpiredReadyChecks(ctx, "g1", now)
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	expired, err = b.Storage.ExpiredReadyChecks(ctx, "g1", acceptBy)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, expired)
+	expired, err = b.Storage.ExpiredReadyChecks(ctx, "g1", acceptBy.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, expired)
+	require.NoError(t, b.Storage.RemoveReadyCheck(ctx, "g1", "m1"))
+	require.NoError(t, b.Storage.RemoveReadyCheck(ctx, "g1", "m1"))
+	expired, err = b.Storage.ExpiredReadyChecks(ctx, "g1", acceptBy.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	// Requests in a ready check outlive their TTL until the accept deadline plus the TTL
+	b.FastForward(85 * time.Second)
+	_, err = b.Storage.GetMatchRequest(ctx, "r1")
+	require.NoError(t, err)
+	_, err = b.Storage.GetMatchRequest(ctx, "r3")
+	assert.Error(t, err)
+	b.FastForward(10 * time.Second)
+	_, err = b.Storage.GetMatchRequest(ctx, "r1")
+	assert.Error(t, err)
+}
+
+func testGameLockExclusive(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	acquired, err := b.Storage.AcquireGameLock(ctx, "g1", "a", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g1", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired, "a second owner must not take a held lock")
+
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g1", "a", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired, "the current owner must not re-acquire a held lock")
+
+	// Locks are per game
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g2", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func testGameLockRenewAndRelease(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	acquired, err := b.Storage.AcquireGameLock(ctx, "g1", "a", 10*time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	renewed, err := b.Storage.RenewGameLock(ctx, "g1", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, renewed, "only the owner may renew")
+
+	// Renewing pushes the expiry out
+	b.FastForward(8 * time.Second)
+	renewed, err = b.Storage.RenewGameLock(ctx, "g1", "a", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	b.FastForward(8 * time.Second)
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g1", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// Releasing someone else's lock does nothing
+	require.NoError(t, b.Storage.ReleaseGameLock(ctx, "g1", "b"))
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g1", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, b.Storage.ReleaseGameLock(ctx, "g1", "a"))
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g1", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func testGameLockExpiry(t *testing.T, b Backend) {
+	ctx := context.Background()
+
+	acquired, err := b.Storage.AcquireGameLock(ctx, "g1", "a", 10*time.Second)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	b.FastForward(11 * time.Second)
+
+	renewed, err := b.Storage.RenewGameLock(ctx, "g1", "a", 10*time.Second)
+	require.NoError(t, err)
+	assert.False(t, renewed, "an expired lock cannot be renewed")
+
+	acquired, err = b.Storage.AcquireGameLock(ctx, "g1", "b", 10*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}